@@ -0,0 +1,20 @@
+package client
+
+// SetRateLimits changes the server's bandwidth limits in bytes per second;
+// negative values leave a limit as it is and zero removes it. The server only
+// accepts this from its own machine. It returns the limits now in force.
+func (c *Client) SetRateLimits(global int64, perUser int64, perConnection int64) (string, error) {
+	if err := c.begin(); err != nil {
+		return "", err
+	}
+	defer c.end()
+
+	if err := c.msgHandler.SendSetRateLimits(global, perUser, perConnection); err != nil {
+		return "", err
+	}
+	ok, msg := c.msgHandler.ReceiveResponse()
+	if !ok {
+		return "", c.refused("rate change", msg)
+	}
+	return msg, nil
+}
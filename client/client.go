@@ -1,116 +0,0 @@
-package main
-
-import (
-	"crypto/md5"
-	"file-transfer/messages"
-	"file-transfer/util"
-	"fmt"
-	"io"
-	"log"
-	"net"
-	"os"
-	"strings"
-)
-
-func put(msgHandler *messages.MessageHandler, fileName string) int {
-	fmt.Println("PUT", fileName)
-
-	// Get file size and make sure it exists
-	info, err := os.Stat(fileName)
-	if err != nil {
-		log.Fatalln(err)
-	}
-
-	// Tell the server we want to store this file
-	msgHandler.SendStorageRequest(fileName, uint64(info.Size()))
-	if ok, _ := msgHandler.ReceiveResponse(); !ok {
-		return 1
-	}
-
-	file, _ := os.Open(fileName)
-	md5 := md5.New()
-	w := io.MultiWriter(msgHandler, md5)
-	io.CopyN(w, file, info.Size()) // Checksum and transfer file at same time
-	file.Close()
-
-	checksum := md5.Sum(nil)
-	msgHandler.SendChecksumVerification(checksum)
-	if ok, _ := msgHandler.ReceiveResponse(); !ok {
-		return 1
-	}
-
-	fmt.Println("Storage complete!")
-	return 0
-}
-
-func get(msgHandler *messages.MessageHandler, fileName string) int {
-	fmt.Println("GET", fileName)
-
-	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
-	if err != nil {
-		log.Println(err)
-		return 1
-	}
-
-	msgHandler.SendRetrievalRequest(fileName)
-	ok, _, size := msgHandler.ReceiveRetrievalResponse()
-	if !ok {
-		return 1
-	}
-
-	md5 := md5.New()
-	w := io.MultiWriter(file, md5)
-	io.CopyN(w, msgHandler, int64(size))
-	file.Close()
-
-	clientCheck := md5.Sum(nil)
-	checkMsg, _ := msgHandler.Receive()
-	serverCheck := checkMsg.GetChecksum().Checksum
-
-	if util.VerifyChecksum(serverCheck, clientCheck) {
-		log.Println("Successfully retrieved file.")
-	} else {
-		log.Println("FAILED to retrieve file. Invalid checksum.")
-	}
-
-	return 0
-}
-
-func main() {
-	if len(os.Args) < 4 {
-		fmt.Printf("Not enough arguments. Usage: %s server:port put|get file-name [download-dir]\n", os.Args[0])
-		os.Exit(1)
-	}
-
-	host := os.Args[1]
-	conn, err := net.Dial("tcp", host)
-	if err != nil {
-		log.Fatalln(err.Error())
-		return
-	}
-	msgHandler := messages.NewMessageHandler(conn)
-	defer conn.Close()
-
-	action := strings.ToLower(os.Args[2])
-	if action != "put" && action != "get" {
-		log.Fatalln("Invalid action", action)
-	}
-
-	fileName := os.Args[3]
-
-	dir := "."
-	if len(os.Args) >= 5 {
-		dir = os.Args[4]
-	}
-	openDir, err := os.Open(dir)
-	if err != nil {
-		log.Fatalln(err)
-	}
-	openDir.Close()
-
-	if action == "put" {
-		os.Exit(put(msgHandler, fileName))
-	} else if action == "get" {
-		os.Exit(get(msgHandler, fileName))
-	}
-}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"file-transfer/messages"
+	"io/fs"
+)
+
+// ErrChecksum means a file did not arrive intact, on either side.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// ErrSealedName means a file encrypted end-to-end was to be renamed, which
+// it cannot be: it only decrypts under the name it was stored as.
+var ErrSealedName = errors.New("encrypted files cannot be renamed")
+
+// A ServerError is the server's refusal of a request, or its report that a
+// request it accepted failed. errors.Is matches it against fs.ErrNotExist,
+// fs.ErrExist and ErrChecksum when the server's error code says so.
+type ServerError struct {
+	Request string // what was asked for, such as "storage" or "stat"
+	Message string // the server's explanation
+	Failed  bool   // the request was accepted but did not succeed
+	Code    messages.ErrorCode
+}
+
+func (e *ServerError) Error() string {
+	if e.Failed {
+		return e.Request + " failed: " + e.Message
+	}
+	return "server refused " + e.Request + ": " + e.Message
+}
+
+func (e *ServerError) Is(target error) bool {
+	switch target {
+	case fs.ErrNotExist:
+		return e.Code == messages.ErrorCode_NOT_FOUND
+	case fs.ErrExist:
+		return e.Code == messages.ErrorCode_EXISTS
+	case ErrChecksum:
+		return e.Code == messages.ErrorCode_CHECKSUM_MISMATCH
+	}
+	return false
+}
+
+// refused and failed describe the response just received on c.
+func (c *Client) refused(request string, msg string) error {
+	return &ServerError{Request: request, Message: msg, Code: c.msgHandler.ErrorCode()}
+}
+
+func (c *Client) failed(request string, msg string) error {
+	return &ServerError{Request: request, Message: msg, Failed: true, Code: c.msgHandler.ErrorCode()}
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// StartKeepalive pings the server every interval while c is idle, so that
+// the connection survives NATs and firewalls that drop quiet connections.
+// Once misses pings in a row go unanswered the connection is considered
+// dead, and the next transfer reconnects first. Multiplexed connections are
+// pinged on their control stream whether idle or not, and are closed instead.
+func (c *Client) StartKeepalive(interval time.Duration, misses int) {
+	if interval <= 0 || misses <= 0 || c.keepaliveInterval > 0 {
+		return
+	}
+	c.mu.Lock()
+	c.keepaliveInterval = interval
+	c.keepaliveMisses = misses
+	c.lastActive = time.Now()
+	c.mu.Unlock()
+
+	if c.mux != nil {
+		c.mux.Keepalive(interval, misses)
+		return
+	}
+	c.keepaliveStop = make(chan struct{})
+	go c.keepalive(c.keepaliveStop)
+}
+
+func (c *Client) stopKeepalive() {
+	if c.keepaliveStop != nil {
+		close(c.keepaliveStop)
+		c.keepaliveStop = nil
+	}
+}
+
+func (c *Client) keepalive(stop chan struct{}) {
+	ticker := time.NewTicker(c.keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		if c.busy > 0 || c.dead || time.Since(c.lastActive) < c.keepaliveInterval {
+			c.mu.Unlock()
+			continue
+		}
+		c.pingSeq++
+		err := c.msgHandler.SendPing(c.pingSeq, c.keepaliveInterval, uint32(c.keepaliveMisses))
+		broken := err != nil
+		if err == nil {
+			c.unanswered++
+			err = c.awaitPongs(c.keepaliveInterval)
+			broken = err != nil && !errors.Is(err, os.ErrDeadlineExceeded)
+		}
+		switch {
+		case err == nil:
+			c.missed = 0
+		case broken:
+			c.logf("Connection lost: %v", err)
+			c.markDead()
+		default:
+			if c.missed++; c.missed >= c.keepaliveMisses {
+				c.logf("Server is not responding: %v", err)
+				c.markDead()
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// awaitPongs reads the pongs for all unanswered pings, waiting at most
+// timeout. It must be called with c.mu held.
+func (c *Client) awaitPongs(timeout time.Duration) error {
+	c.msgHandler.SetReadDeadline(time.Now().Add(timeout))
+	defer c.msgHandler.SetReadDeadline(time.Time{})
+	for c.unanswered > 0 {
+		wrapper, err := c.msgHandler.Receive()
+		if err != nil {
+			return err
+		}
+		if wrapper.GetPong() == nil {
+			return fmt.Errorf("expected pong, got %T", wrapper.Msg)
+		}
+		c.unanswered--
+	}
+	return nil
+}
+
+// markDead closes a connection that is no longer usable. It must be called
+// with c.mu held.
+func (c *Client) markDead() {
+	c.dead = true
+	c.unanswered = 0
+	c.missed = 0
+	c.msgHandler.Close()
+}
+
+// begin has to be called at the start of every transfer, and end once it is
+// over. Transfers may nest. The outermost one makes sure the connection is
+// alive, reconnecting if necessary.
+func (c *Client) begin() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.busy == 0 {
+		if !c.dead && c.unanswered > 0 {
+			if err := c.awaitPongs(c.keepaliveInterval); err != nil {
+				c.logf("Server is not responding: %v", err)
+				c.markDead()
+			}
+		}
+		if c.dead {
+			if err := c.reconnect(); err != nil {
+				return fmt.Errorf("unable to reconnect: %w", err)
+			}
+		}
+	}
+	c.busy++
+	c.msgHandler.SetLogger(c.Logger)
+	return nil
+}
+
+func (c *Client) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.busy--
+	c.lastActive = time.Now()
+}
+
+// reconnect replaces a dead connection with a new one. It must be called
+// with c.mu held.
+func (c *Client) reconnect() error {
+	conn, err := DialTimeout(c.host, c.connectTimeout, c.stallTimeout)
+	if err != nil {
+		return err
+	}
+	c.stopWatching()
+	c.msgHandler = conn.msgHandler
+	c.stopWatching = func() {}
+	if c.ctx != nil {
+		c.stopWatching = c.msgHandler.Watch(c.ctx)
+	}
+	c.dead = false
+	c.logf("Reconnected to %s", c.host)
+	return nil
+}
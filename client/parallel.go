@@ -0,0 +1,192 @@
+package client
+
+import (
+	"crypto/md5"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Smaller files are not worth the extra connections.
+const MinParallelSize = 8 * 1024 * 1024
+
+type byteRange struct {
+	offset int64
+	length int64
+}
+
+// splitRanges divides size bytes into at most n ranges of (nearly) equal
+// length.
+func splitRanges(size int64, n int) []byteRange {
+	if int64(n) > size {
+		n = int(size)
+	}
+	ranges := make([]byteRange, 0, n)
+	offset := int64(0)
+	for i := 0; i < n; i++ {
+		length := (size - offset) / int64(n-i)
+		ranges = append(ranges, byteRange{offset, length})
+		offset += length
+	}
+	return ranges
+}
+
+// eachRange runs transfer for every range at once, each on a new connection,
+// and returns the first error.
+func (c *Client) eachRange(ranges []byteRange, transfer func(*Client, byteRange) error) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ranges))
+	for _, r := range ranges {
+		wg.Add(1)
+		go func(r byteRange) {
+			defer wg.Done()
+			conn, err := c.dial()
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer conn.Close()
+			if err := transfer(conn, r); err != nil {
+				errs <- fmt.Errorf("range %d+%d: %w", r.offset, r.length, err)
+			}
+		}(r)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+func (c *Client) putParallel(file *os.File, fileName string, size int64, metadata *messages.FileMetadata) error {
+	ranges := splitRanges(size, c.Connections)
+	if err := c.msgHandler.SendParallelStorageRequest(fileName, uint64(size), uint32(len(ranges)), metadata, c.Overwrite); err != nil {
+		return err
+	}
+	ok, msg, transferID := c.msgHandler.ReceiveTransferResponse()
+	if !ok {
+		return c.refused("storage", msg)
+	}
+
+	// The whole-file checksum is computed alongside the uploads
+	checksum := make(chan []byte)
+	go func() {
+		md5 := md5.New()
+		io.Copy(md5, io.NewSectionReader(file, 0, size))
+		checksum <- md5.Sum(nil)
+	}()
+
+	rangeErr := c.eachRange(ranges, func(conn *Client, r byteRange) error {
+		return conn.putRange(file, transferID, r)
+	})
+
+	// Even if a range failed the server is waiting for the checksum, and will
+	// then discard the incomplete upload.
+	if err := c.msgHandler.SendChecksumVerification(<-checksum); err != nil {
+		return err
+	}
+	ok, msg = c.msgHandler.ReceiveResponse()
+	if rangeErr != nil {
+		return rangeErr
+	}
+	if !ok {
+		return c.failed("storage", msg)
+	}
+	return nil
+}
+
+func (c *Client) putRange(file *os.File, transferID string, r byteRange) error {
+	if err := c.msgHandler.SendRangeStorageRequest(transferID, uint64(r.offset), uint64(r.length)); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("range", msg)
+	}
+
+	md5 := md5.New()
+	w := io.MultiWriter(c.msgHandler, md5)
+	if _, err := io.Copy(w, c.limit(io.NewSectionReader(file, r.offset, r.length))); err != nil {
+		return err
+	}
+	if err := c.msgHandler.SendChecksumVerification(md5.Sum(nil)); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.failed("range", msg)
+	}
+	return nil
+}
+
+// getParallel downloads the ranges into a temporary file next to file, and
+// only fills in file once the whole thing has been verified (and decrypted,
+// if it was encrypted end-to-end).
+func (c *Client) getParallel(file *os.File, fileName string, size int64) (*messages.FileMetadata, error) {
+	dir, base := filepath.Split(file.Name())
+	temp, err := os.CreateTemp(dir, "."+base+".part-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+	if err := temp.Truncate(size); err != nil {
+		return nil, err
+	}
+
+	// The server computes the whole-file checksum while the ranges download
+	if err := c.msgHandler.SendStatRequest(fileName, true); err != nil {
+		return nil, err
+	}
+
+	ranges := splitRanges(size, c.Connections)
+	rangeErr := c.eachRange(ranges, func(conn *Client, r byteRange) error {
+		return conn.getRange(temp, fileName, r)
+	})
+
+	ok, msg, _, serverCheck, metadata, encrypted := c.msgHandler.ReceiveStatResponse()
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	if !ok {
+		return nil, c.refused("retrieval", msg)
+	}
+
+	md5 := md5.New()
+	if _, err := io.Copy(md5, io.NewSectionReader(temp, 0, size)); err != nil {
+		return nil, err
+	}
+	if !util.VerifyChecksum(serverCheck, md5.Sum(nil)) {
+		return nil, ErrChecksum
+	}
+
+	body := io.NewSectionReader(temp, 0, size)
+	if err := c.writeContents(file, body, fileName, encrypted); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func (c *Client) getRange(temp *os.File, fileName string, r byteRange) error {
+	if err := c.msgHandler.SendRangeRetrievalRequest(fileName, uint64(r.offset), uint64(r.length)); err != nil {
+		return err
+	}
+	ok, msg, size, _, _ := c.msgHandler.ReceiveRetrievalResponse()
+	if !ok {
+		return c.refused("range", msg)
+	}
+
+	md5 := md5.New()
+	w := io.MultiWriter(util.NewOffsetWriter(temp, r.offset), md5)
+	if _, err := io.CopyN(w, c.limit(c.msgHandler), int64(size)); err != nil {
+		return err
+	}
+	serverCheck, err := c.msgHandler.ReceiveChecksum()
+	if err != nil {
+		return err
+	}
+	if !util.VerifyChecksum(serverCheck, md5.Sum(nil)) {
+		return ErrChecksum
+	}
+	return nil
+}
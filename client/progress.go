@@ -0,0 +1,157 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// How often Progress is called if ProgressInterval is not set.
+const DefaultProgressInterval = 500 * time.Millisecond
+
+// A Progress describes how far a put or get has got.
+type Progress struct {
+	Name    string // the name the file is stored under
+	Bytes   int64  // transferred so far
+	Total   int64  // the size of the transfer, or -1 if it is not known
+	Elapsed time.Duration
+}
+
+// Rate is the average throughput so far, in bytes per second.
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / p.Elapsed.Seconds()
+}
+
+// Remaining estimates the time left at the average rate so far, or returns
+// -1 if there is nothing to base an estimate on.
+func (p Progress) Remaining() time.Duration {
+	rate := p.Rate()
+	if p.Total < 0 || rate == 0 {
+		return -1
+	}
+	return time.Duration(float64(p.Total-p.Bytes) / rate * float64(time.Second))
+}
+
+// The outcomes of the checksum comparison at the end of a transfer.
+const (
+	ChecksumVerified  = "verified"
+	ChecksumMismatch  = "mismatch"
+	ChecksumUnchecked = "unchecked" // the transfer failed before the comparison
+)
+
+// TransferStats summarize a finished put or get.
+type TransferStats struct {
+	Name     string
+	Bytes    int64
+	Duration time.Duration
+	Checksum string
+	Err      error
+}
+
+// Rate is the average throughput, in bytes per second.
+func (s TransferStats) Rate() float64 {
+	return Progress{Bytes: s.Bytes, Elapsed: s.Duration}.Rate()
+}
+
+// A tracker counts the bytes of the transfer under way, including those
+// sent or received over extra connections.
+type tracker struct {
+	name    string
+	start   time.Time
+	bytes   atomic.Int64
+	total   atomic.Int64
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (t *tracker) progress() Progress {
+	return Progress{
+		Name:    t.name,
+		Bytes:   t.bytes.Load(),
+		Total:   t.total.Load(),
+		Elapsed: time.Since(t.start),
+	}
+}
+
+// setTotal records the size of the transfer once it is known.
+func (t *tracker) setTotal(total int64) {
+	if t != nil {
+		t.total.Store(total)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	t *tracker
+}
+
+func (cr countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.t.bytes.Add(int64(n))
+	return n, err
+}
+
+// track follows the transfer of name, total bytes long (or -1 if that is not
+// known yet), until the returned function is called with its outcome. Only
+// the data passed through limit is counted.
+func (c *Client) track(name string, total int64) func(error) {
+	if c.Progress == nil && c.Done == nil {
+		return func(error) {}
+	}
+	t := &tracker{
+		name:    name,
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	t.total.Store(total)
+	c.tracker = t
+
+	interval := c.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	go func() {
+		defer close(t.stopped)
+		if c.Progress == nil {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.Progress(t.progress())
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+
+	return func(err error) {
+		close(t.stop)
+		<-t.stopped
+		c.tracker = nil
+		final := t.progress()
+		if c.Progress != nil {
+			c.Progress(final)
+		}
+		if c.Done == nil {
+			return
+		}
+		stats := TransferStats{Name: name, Bytes: final.Bytes, Duration: final.Elapsed, Err: err}
+		switch {
+		case err == nil:
+			stats.Checksum = ChecksumVerified
+		case errors.Is(err, ErrChecksum):
+			stats.Checksum = ChecksumMismatch
+		default:
+			stats.Checksum = ChecksumUnchecked
+		}
+		c.Done(stats)
+	}
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgressEstimates(t *testing.T) {
+	p := Progress{Bytes: 300, Total: 1000, Elapsed: 3 * time.Second}
+	if rate := p.Rate(); rate != 100 {
+		t.Errorf("rate %v, want 100", rate)
+	}
+	if remaining := p.Remaining(); remaining != 7*time.Second {
+		t.Errorf("remaining %v, want 7s", remaining)
+	}
+
+	// Without a size or any data so far there is nothing to estimate
+	for _, p := range []Progress{
+		{Bytes: 300, Total: -1, Elapsed: 3 * time.Second},
+		{Bytes: 0, Total: 1000, Elapsed: 3 * time.Second},
+		{Bytes: 0, Total: 1000},
+	} {
+		if remaining := p.Remaining(); remaining != -1 {
+			t.Errorf("%+v: remaining %v, want -1", p, remaining)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"file-transfer/messages"
+)
+
+// A FileInfo describes a stored file. Checksum is only filled in if it was
+// asked for, and Metadata may be nil. Encrypted files were encrypted
+// end-to-end, and Size and Checksum are those of the ciphertext.
+type FileInfo struct {
+	Name      string
+	Size      uint64
+	Checksum  []byte
+	Metadata  *messages.FileMetadata
+	Encrypted bool
+}
+
+// Stat asks the server about fileName, including the MD5 checksum of its
+// contents if checksum is set.
+func (c *Client) Stat(fileName string, checksum bool) (*FileInfo, error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	if err := c.msgHandler.SendStatRequest(fileName, checksum); err != nil {
+		return nil, err
+	}
+	ok, msg, size, sum, metadata, encrypted := c.msgHandler.ReceiveStatResponse()
+	if !ok {
+		return nil, c.refused("stat", msg)
+	}
+	return &FileInfo{Name: fileName, Size: size, Checksum: sum, Metadata: metadata, Encrypted: encrypted}, nil
+}
+
+// Delete removes a stored file, or a directory if it is empty.
+func (c *Client) Delete(fileName string) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if err := c.msgHandler.SendDeleteRequest(fileName); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("deletion", msg)
+	}
+	return nil
+}
+
+// Rename gives a stored file or directory a new name. Nothing already stored
+// under the new name is replaced. Files encrypted end-to-end are refused, and
+// those inside a renamed directory no longer decrypt.
+func (c *Client) Rename(from string, to string) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+
+	if info, err := c.Stat(from, false); err == nil && info.Encrypted {
+		return ErrSealedName
+	}
+
+	if err := c.msgHandler.SendRenameRequest(from, to); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("rename", msg)
+	}
+	return nil
+}
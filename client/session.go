@@ -0,0 +1,543 @@
+package client
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"file-transfer/encryption"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// A Client holds one connection to the server and runs any number of puts
+// and gets over it, one after another.
+type Client struct {
+	msgHandler *messages.MessageHandler
+	mux        *messages.Mux
+
+	host           string
+	connectTimeout time.Duration
+	stallTimeout   time.Duration
+	ctx            context.Context
+	stopWatching   func()
+
+	// Guards the connection against the keepalive goroutine
+	mu                sync.Mutex
+	busy              int
+	lastActive        time.Time
+	dead              bool
+	pingSeq           uint64
+	unanswered        int
+	missed            int
+	keepaliveInterval time.Duration
+	keepaliveMisses   int
+	keepaliveStop     chan struct{}
+
+	// Keys for end-to-end encryption. Puts are only encrypted if Encrypt is
+	// set; gets decrypt whenever the stored file is encrypted.
+	Keys    *encryption.KeySource
+	Encrypt bool
+
+	// Files of at least MinParallelSize are split into this many ranges,
+	// each transferred over its own extra connection.
+	Connections int
+
+	// Preserve keeps the permission bits and modification time of files,
+	// and Xattrs additionally their user extended attributes.
+	Preserve bool
+	Xattrs   bool
+
+	// Limit caps the bandwidth of all transfers together, including those
+	// over extra connections and streams. Nil means no limit.
+	Limit *util.RateLimiter
+
+	// Overwrite replaces files that already exist, on the server for puts
+	// and locally for gets, instead of failing.
+	Overwrite bool
+
+	// Progress is called every ProgressInterval while a put or get is
+	// running and once more when it ends, and Done with a summary of each
+	// finished put and get. Neither is called concurrently for one Client.
+	Progress         func(Progress)
+	ProgressInterval time.Duration
+	Done             func(TransferStats)
+	tracker          *tracker
+
+	// Logger, if set, is told what the server says and what happens to the
+	// connection, such as reconnecting.
+	Logger *log.Logger
+}
+
+func Dial(host string) (*Client, error) {
+	return DialTimeout(host, 0, 0)
+}
+
+// DialTimeout connects like Dial, giving up on connecting (and switching to
+// multiplexed mode) after connect, and aborting transfers that make no
+// progress for stall. Zero means no limit.
+func DialTimeout(host string, connect time.Duration, stall time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", host, connect)
+	if err != nil {
+		return nil, err
+	}
+	msgHandler := messages.NewMessageHandler(conn)
+	msgHandler.SetStallTimeout(stall)
+	msgHandler.AnswerPings()
+	return &Client{
+		msgHandler:     msgHandler,
+		host:           host,
+		connectTimeout: connect,
+		stallTimeout:   stall,
+		stopWatching:   func() {},
+	}, nil
+}
+
+// Watch aborts whatever c is doing once ctx is done, including transfers
+// over extra connections and streams opened later.
+func (c *Client) Watch(ctx context.Context) {
+	c.stopWatching()
+	c.ctx = ctx
+	c.stopWatching = c.msgHandler.Watch(ctx)
+}
+
+// dial opens another connection to the same server with the same settings.
+func (c *Client) dial() (*Client, error) {
+	conn, err := DialTimeout(c.host, c.connectTimeout, c.stallTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if c.ctx != nil {
+		conn.Watch(c.ctx)
+	}
+	conn.Limit = c.Limit
+	conn.tracker = c.tracker
+	conn.Logger = c.Logger
+	conn.msgHandler.SetLogger(c.Logger)
+	return conn, nil
+}
+
+// logf passes a message on to c.Logger, if there is one.
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+	}
+}
+
+// limit applies the bandwidth limit to data read from r, and counts it
+// towards the progress of the transfer under way.
+func (c *Client) limit(r io.Reader) io.Reader {
+	r = util.Throttle{c.Limit}.Reader(r)
+	if c.tracker != nil {
+		r = countingReader{r, c.tracker}
+	}
+	return r
+}
+
+// Close ends the session politely and closes the connection.
+func (c *Client) Close() error {
+	c.stopKeepalive()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.stopWatching()
+	if c.mux != nil {
+		return c.mux.Close()
+	}
+	if c.dead {
+		return nil
+	}
+	err := c.msgHandler.SendGoodbye("")
+	c.msgHandler.Close()
+	return err
+}
+
+// Multiplex switches the connection to multiplexed mode. Afterwards c can
+// only be used to open streams, each of which is an independent Client
+// whose transfers share the connection with the others.
+func (c *Client) Multiplex() error {
+	c.stopKeepalive()
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+
+	wrapper := &messages.Wrapper{
+		Msg: &messages.Wrapper_MuxStart{MuxStart: &messages.MuxStart{}},
+	}
+	if err := c.msgHandler.Send(wrapper); err != nil {
+		return err
+	}
+	if c.connectTimeout > 0 {
+		c.msgHandler.SetReadDeadline(time.Now().Add(c.connectTimeout))
+		defer c.msgHandler.SetReadDeadline(time.Time{})
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("multiplexing", msg)
+	}
+	c.mux = messages.NewMux(c.msgHandler, false)
+	c.mux.Keepalive(c.keepaliveInterval, c.keepaliveMisses)
+	return nil
+}
+
+func (c *Client) Stream() (*Client, error) {
+	if c.mux == nil {
+		return nil, errors.New("connection is not multiplexed")
+	}
+	stream, err := c.mux.Open()
+	if err != nil {
+		return nil, err
+	}
+	msgHandler := messages.NewMessageHandler(stream)
+	msgHandler.SetStallTimeout(c.stallTimeout)
+	msgHandler.SetLogger(c.Logger)
+	s := &Client{
+		msgHandler:     msgHandler,
+		host:           c.host,
+		connectTimeout: c.connectTimeout,
+		stallTimeout:   c.stallTimeout,
+		stopWatching:   func() {},
+		Keys:           c.Keys,
+		Encrypt:        c.Encrypt,
+		Connections:    c.Connections,
+		Preserve:       c.Preserve,
+		Xattrs:         c.Xattrs,
+		Limit:          c.Limit,
+		Overwrite:      c.Overwrite,
+
+		Progress:         c.Progress,
+		ProgressInterval: c.ProgressInterval,
+		Done:             c.Done,
+		Logger:           c.Logger,
+	}
+	if c.ctx != nil {
+		s.Watch(c.ctx)
+	}
+	return s, nil
+}
+
+// watch aborts the transfer under way once ctx is done. The connection is
+// of no further use then, so the next transfer reconnects.
+func (c *Client) watch(ctx context.Context) (stop func()) {
+	stopWatching := c.msgHandler.Watch(ctx)
+	return func() {
+		stopWatching()
+		if ctx.Err() != nil {
+			c.mu.Lock()
+			c.markDead()
+			c.mu.Unlock()
+		}
+	}
+}
+
+// Put stores size bytes read from r as fileName on the server. A negative
+// size means the length is not known in advance, so r can be a pipe, but
+// then the data cannot be encrypted. Cancelling ctx aborts the upload.
+func (c *Client) Put(ctx context.Context, fileName string, r io.Reader, size int64) (err error) {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	defer c.watch(ctx)()
+	done := c.track(fileName, size)
+	defer func() { done(err) }()
+
+	if size < 0 {
+		return c.sendChunked(r, fileName)
+	}
+	return c.send(r, fileName, size, nil)
+}
+
+// PutFile stores the local file at path under fileName on the server.
+func (c *Client) PutFile(ctx context.Context, path string, fileName string) (err error) {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	defer c.watch(ctx)()
+
+	// Get file size and make sure it exists
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	done := c.track(fileName, info.Size())
+	defer func() { done(err) }()
+
+	var metadata *messages.FileMetadata
+	if c.Preserve || c.Xattrs {
+		if metadata, err = util.ReadMetadata(path, c.Xattrs); err != nil {
+			return err
+		}
+	}
+
+	// Holes are cheaper to describe than to send, even over many connections
+	if !c.Encrypt {
+		extents, sparse, err := util.DataExtents(file, info.Size())
+		if err != nil {
+			return err
+		}
+		if sparse {
+			return c.putSparse(file, fileName, info.Size(), extents, metadata)
+		}
+	}
+
+	if c.Connections > 1 && info.Size() >= MinParallelSize {
+		if !c.Encrypt {
+			return c.putParallel(file, fileName, info.Size(), metadata)
+		}
+		c.logf("Encrypted files are uploaded over a single connection")
+	}
+	return c.send(file, fileName, info.Size(), metadata)
+}
+
+// send stores size bytes read from r as fileName over this connection,
+// encrypting them if c.Encrypt is set. If r runs out early the server is
+// still waiting for the rest, so the connection is dropped.
+func (c *Client) send(r io.Reader, fileName string, size int64, metadata *messages.FileMetadata) error {
+	stored := size
+	meta := encryption.Metadata{Name: fileName, Size: size}
+	if c.Encrypt {
+		if c.Keys == nil {
+			return errors.New("encryption needs a key file or passphrase")
+		}
+		stored = encryption.EncryptedSize(meta)
+	}
+
+	// Tell the server we want to store this file
+	if err := c.msgHandler.SendStorageRequest(fileName, uint64(stored), metadata, c.Overwrite, c.Encrypt); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("storage", msg)
+	}
+
+	md5 := md5.New()
+	w := io.MultiWriter(c.msgHandler, md5)
+	var err error
+	if c.Encrypt {
+		// The server only ever sees (and checksums) the ciphertext
+		var enc io.WriteCloser
+		if enc, err = encryption.Encrypt(w, c.Keys, meta); err == nil {
+			if _, err = io.CopyN(enc, c.limit(r), size); err == nil {
+				err = enc.Close()
+			}
+		}
+	} else {
+		_, err = io.CopyN(w, c.limit(r), size) // Checksum and transfer file at same time
+	}
+	if err != nil {
+		c.mu.Lock()
+		c.markDead()
+		c.mu.Unlock()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	checksum := md5.Sum(nil)
+	if err := c.msgHandler.SendChecksumVerification(checksum); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.failed("storage", msg)
+	}
+	return nil
+}
+
+// sendChunked stores everything read from r as fileName, without knowing
+// its length in advance.
+func (c *Client) sendChunked(r io.Reader, fileName string) error {
+	if c.Encrypt {
+		return errors.New("streams of unknown length cannot be encrypted")
+	}
+	if err := c.msgHandler.SendChunkedStorageRequest(fileName, c.Overwrite); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("storage", msg)
+	}
+
+	md5 := md5.New()
+	chunks := messages.NewChunkedWriter(c.msgHandler)
+	_, copyErr := io.Copy(io.MultiWriter(chunks, md5), c.limit(r))
+	if err := chunks.Close(); err != nil {
+		return err
+	}
+
+	// If reading failed the server still needs a checksum to finish the
+	// upload; an empty one makes sure it is discarded.
+	checksum := md5.Sum(nil)
+	if copyErr != nil {
+		checksum = nil
+	}
+	if err := c.msgHandler.SendChecksumVerification(checksum); err != nil {
+		return err
+	}
+	ok, msg := c.msgHandler.ReceiveResponse()
+	if copyErr != nil {
+		return copyErr
+	}
+	if !ok {
+		return c.failed("storage", msg)
+	}
+	return nil
+}
+
+// createPartial makes sure fileName is free, unless it is to be overwritten,
+// and returns a temporary file next to it for a download to go to.
+func createPartial(fileName string, overwrite bool) (*os.File, error) {
+	if _, err := os.Lstat(fileName); err == nil && !overwrite {
+		return nil, &fs.PathError{Op: "open", Path: fileName, Err: fs.ErrExist}
+	}
+	dir, base := filepath.Split(fileName)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return os.CreateTemp(dir, "."+base+".part-*")
+}
+
+// GetFile downloads fileName to a temporary file and only moves it into place
+// at path once it has been verified, so an existing local file is always
+// complete.
+func (c *Client) GetFile(ctx context.Context, fileName string, path string) (err error) {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	defer c.watch(ctx)()
+	done := c.track(fileName, -1)
+	defer func() { done(err) }()
+
+	file, err := createPartial(path, c.Overwrite)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	metadata, err := c.get(file, fileName)
+	if err != nil {
+		return err
+	}
+	if (c.Preserve || c.Xattrs) && metadata != nil {
+		err = util.ApplyMetadata(file, metadata, c.Xattrs, 0)
+	} else {
+		err = file.Chmod(0644)
+	}
+	if err != nil {
+		return err
+	}
+	return commitPartial(file.Name(), path, c.Overwrite)
+}
+
+// commitPartial moves a complete download into place. Unless it may
+// overwrite, it does not clobber a file created in the meantime.
+func commitPartial(partial string, fileName string, overwrite bool) error {
+	if overwrite {
+		return os.Rename(partial, fileName)
+	}
+	return os.Link(partial, fileName)
+}
+
+// get fills in file and returns the metadata the server has for it.
+func (c *Client) get(file *os.File, fileName string) (*messages.FileMetadata, error) {
+	if c.Connections > 1 {
+		if err := c.msgHandler.SendStatRequest(fileName, false); err != nil {
+			return nil, err
+		}
+		ok, msg, size, _, _, _ := c.msgHandler.ReceiveStatResponse()
+		if !ok {
+			return nil, c.refused("retrieval", msg)
+		}
+		if size >= MinParallelSize {
+			c.tracker.setTotal(int64(size))
+			return c.getParallel(file, fileName, int64(size))
+		}
+	}
+	return c.retrieve(file, fileName)
+}
+
+// Get writes fileName to w as it arrives. The checksum can only be verified
+// at the end, so w may have received a corrupt file if an error is returned.
+// Cancelling ctx aborts the download.
+func (c *Client) Get(ctx context.Context, fileName string, w io.Writer) (err error) {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	defer c.watch(ctx)()
+	done := c.track(fileName, -1)
+	defer func() { done(err) }()
+
+	_, err = c.retrieve(w, fileName)
+	return err
+}
+
+// retrieve downloads fileName over this connection alone.
+func (c *Client) retrieve(w io.Writer, fileName string) (*messages.FileMetadata, error) {
+	if err := c.msgHandler.SendRetrievalRequest(fileName); err != nil {
+		return nil, err
+	}
+	ok, msg, size, metadata, encrypted := c.msgHandler.ReceiveRetrievalResponse()
+	if !ok {
+		return nil, c.refused("retrieval", msg)
+	}
+	c.tracker.setTotal(int64(size))
+
+	md5 := md5.New()
+	body := io.TeeReader(io.LimitReader(c.limit(c.msgHandler), int64(size)), md5)
+	copyErr := c.writeContents(w, body, fileName, encrypted)
+
+	// Whatever happened locally, the rest of the transfer has to be consumed
+	// to keep the connection usable.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	clientCheck := md5.Sum(nil)
+	serverCheck, err := c.msgHandler.ReceiveChecksum()
+	if err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return nil, copyErr
+	}
+
+	if !util.VerifyChecksum(serverCheck, clientCheck) {
+		return nil, ErrChecksum
+	}
+	return metadata, nil
+}
+
+// writeContents copies the retrieved file fileName to w, decrypting it if the
+// server says it was encrypted end-to-end.
+func (c *Client) writeContents(w io.Writer, body io.Reader, fileName string, encrypted bool) error {
+	if !encrypted {
+		_, err := io.Copy(w, body)
+		return err
+	}
+
+	dec, _, err := encryption.Decrypt(body, c.Keys, fileName)
+	if err == nil {
+		_, err = io.Copy(w, dec)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to decrypt file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"crypto/md5"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"io"
+	"os"
+)
+
+// putSparse sends only the data extents of a file with holes. The checksum
+// still covers the whole file, holes included.
+func (c *Client) putSparse(file *os.File, fileName string, size int64, extents []*messages.Extent, metadata *messages.FileMetadata) error {
+	if err := c.msgHandler.SendSparseStorageRequest(fileName, uint64(size), extents, metadata, c.Overwrite); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.refused("storage", msg)
+	}
+	// Only the data counts towards progress, not the holes
+	dataSize := int64(0)
+	for _, extent := range extents {
+		dataSize += int64(extent.Length)
+	}
+	c.tracker.setTotal(dataSize)
+
+	md5 := md5.New()
+	offset := int64(0)
+	for _, extent := range extents {
+		if err := util.WriteZeros(md5, int64(extent.Offset)-offset); err != nil {
+			return err
+		}
+		data := c.limit(io.NewSectionReader(file, int64(extent.Offset), int64(extent.Length)))
+		if _, err := io.CopyN(io.MultiWriter(c.msgHandler, md5), data, int64(extent.Length)); err != nil {
+			return err
+		}
+		offset = int64(extent.Offset + extent.Length)
+	}
+	if err := util.WriteZeros(md5, size-offset); err != nil {
+		return err
+	}
+
+	if err := c.msgHandler.SendChecksumVerification(md5.Sum(nil)); err != nil {
+		return err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return c.failed("storage", msg)
+	}
+	return nil
+}
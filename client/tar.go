@@ -0,0 +1,176 @@
+package client
+
+import (
+	"archive/tar"
+	"crypto/md5"
+	"errors"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// reportEntryErrors passes the entries the server could not handle on to
+// report and adds them to summary.
+func reportEntryErrors(summary *TreeSummary, entryErrors []*messages.TarEntryError, report func(TreeResult)) {
+	for _, entryError := range entryErrors {
+		result := TreeResult{Name: entryError.Name, Err: errors.New(entryError.Message)}
+		summary.add(result)
+		report(result)
+	}
+}
+
+// PutTar sends the directory tree under root as a single tar archive, which
+// the server unpacks below RemoteName(root). This saves a round trip per file.
+// Only entries that fail are reported.
+func (c *Client) PutTar(root string, report func(TreeResult)) (TreeSummary, error) {
+	if err := c.begin(); err != nil {
+		return TreeSummary{}, err
+	}
+	defer c.end()
+
+	var summary TreeSummary
+	if c.Encrypt {
+		return summary, errors.New("archives are unpacked by the server and cannot be encrypted")
+	}
+
+	var headers []*tar.Header
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root || isPartial(path) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			result := TreeResult{Name: path, Err: errors.New("only regular files and directories are supported")}
+			summary.add(result)
+			report(result)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		headers = append(headers, header)
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return summary, err
+	}
+	size, err := util.TarSize(headers)
+	if err != nil {
+		return summary, err
+	}
+
+	if err := c.msgHandler.SendTarStorageRequest(RemoteName(root), uint64(size)); err != nil {
+		return summary, err
+	}
+	if ok, msg := c.msgHandler.ReceiveResponse(); !ok {
+		return summary, c.refused("storage", msg)
+	}
+
+	md5 := md5.New()
+	tw := tar.NewWriter(io.MultiWriter(c.msgHandler, md5))
+	for i, header := range headers {
+		if err := tw.WriteHeader(header); err != nil {
+			return summary, err
+		}
+		if header.Typeflag == tar.TypeReg {
+			// The size was announced, so a file that changed in the meantime
+			// breaks the whole transfer.
+			if err := c.copyFile(tw, paths[i], header.Size); err != nil {
+				return summary, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return summary, err
+	}
+
+	if err := c.msgHandler.SendChecksumVerification(md5.Sum(nil)); err != nil {
+		return summary, err
+	}
+	ok, msg, _, entries, entryErrors := c.msgHandler.ReceiveTarResponse()
+	if !ok {
+		return summary, c.failed("storage", msg)
+	}
+	summary.Transferred = int(entries)
+	reportEntryErrors(&summary, entryErrors, report)
+	return summary, nil
+}
+
+func (c *Client) copyFile(w io.Writer, path string, size int64) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.CopyN(w, c.limit(file), size)
+	return err
+}
+
+// GetTar retrieves the directory tree under root as a single tar archive and
+// saves it as the local file archive once it has been verified. Entries the
+// server left out are reported.
+func (c *Client) GetTar(root string, archive string, report func(TreeResult)) (TreeSummary, error) {
+	if err := c.begin(); err != nil {
+		return TreeSummary{}, err
+	}
+	defer c.end()
+
+	var summary TreeSummary
+	file, err := createPartial(archive, c.Overwrite)
+	if err != nil {
+		return summary, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := c.msgHandler.SendTarRetrievalRequest(root); err != nil {
+		return summary, err
+	}
+	ok, msg, size, entries, entryErrors := c.msgHandler.ReceiveTarResponse()
+	if !ok {
+		return summary, c.refused("retrieval", msg)
+	}
+	reportEntryErrors(&summary, entryErrors, report)
+
+	md5 := md5.New()
+	w := io.MultiWriter(file, md5)
+	if _, err := io.CopyN(w, c.limit(c.msgHandler), int64(size)); err != nil {
+		return summary, err
+	}
+	serverCheck, err := c.msgHandler.ReceiveChecksum()
+	if err != nil {
+		return summary, err
+	}
+	if !util.VerifyChecksum(serverCheck, md5.Sum(nil)) {
+		return summary, ErrChecksum
+	}
+	if err := file.Chmod(0644); err != nil {
+		return summary, err
+	}
+	if err := commitPartial(file.Name(), archive, c.Overwrite); err != nil {
+		return summary, err
+	}
+	summary.Transferred = int(entries)
+	return summary, nil
+}
@@ -0,0 +1,212 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"file-transfer/encryption"
+	"file-transfer/messages"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// TreeResult describes what happened to one file of a directory transfer.
+type TreeResult struct {
+	Name    string
+	Skipped bool
+	Err     error
+}
+
+type TreeSummary struct {
+	Transferred int
+	Skipped     int
+	Failed      int
+}
+
+func (s *TreeSummary) add(result TreeResult) {
+	switch {
+	case result.Err != nil:
+		s.Failed++
+	case result.Skipped:
+		s.Skipped++
+	default:
+		s.Transferred++
+	}
+}
+
+// RemoteName is the name the local file or directory at path is stored
+// under by default: its path if that is relative and stays below the current
+// directory, or else just its base name.
+func RemoteName(path string) string {
+	clean := filepath.Clean(path)
+	if !filepath.IsLocal(clean) {
+		return filepath.Base(clean)
+	}
+	return filepath.ToSlash(clean)
+}
+
+// treeName is the name the file under the local directory root is stored
+// under, keeping its place below RemoteName(root).
+func treeName(root string, file string) (string, error) {
+	rel, err := filepath.Rel(root, file)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(RemoteName(root), filepath.ToSlash(rel)), nil
+}
+
+// isPartial reports whether name is a temporary file left by an unfinished
+// download.
+func isPartial(name string) bool {
+	base := filepath.Base(name)
+	return strings.HasPrefix(base, ".") && strings.Contains(base, ".part-")
+}
+
+func (c *Client) List(path string, recursive bool) ([]*messages.FileEntry, error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+
+	if err := c.msgHandler.SendListRequest(path, recursive); err != nil {
+		return nil, err
+	}
+	ok, msg, entries := c.msgHandler.ReceiveListResponse()
+	if !ok {
+		return nil, c.refused("listing", msg)
+	}
+	return entries, nil
+}
+
+// PutTree stores every file under root, below RemoteName(root). Files the
+// server already has in full are skipped, so an interrupted upload can
+// simply be run again; ones that differ are replaced if Overwrite is set.
+// report is called after each file.
+func (c *Client) PutTree(root string, report func(TreeResult)) (TreeSummary, error) {
+	if err := c.begin(); err != nil {
+		return TreeSummary{}, err
+	}
+	defer c.end()
+
+	var summary TreeSummary
+	remote := make(map[string]*messages.FileEntry)
+	entries, err := c.List(RemoteName(root), true)
+	if err == nil {
+		for _, entry := range entries {
+			remote[entry.Name] = entry
+		}
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || isPartial(path) {
+			return nil
+		}
+
+		name, err := treeName(root, path)
+		if err != nil {
+			return err
+		}
+		result := TreeResult{Name: path}
+		entry := remote[name]
+		switch {
+		case entry != nil && c.hasFile(path, name, entry):
+			result.Skipped = true
+		case entry != nil && !c.Overwrite:
+			result.Err = errors.New("a different file with this name is already stored")
+		default:
+			result.Err = c.PutFile(context.Background(), path, name)
+		}
+		summary.add(result)
+		report(result)
+		return nil
+	})
+	return summary, err
+}
+
+// hasFile reports whether the file stored as name, described by entry, is the
+// same as the local file at path.
+func (c *Client) hasFile(path string, name string, entry *messages.FileEntry) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if c.Encrypt {
+		// Only the server's copy can be checked, and it is ciphertext
+		meta := encryption.Metadata{Name: name, Size: info.Size()}
+		return entry.Size == uint64(encryption.EncryptedSize(meta))
+	}
+	if entry.Size != uint64(info.Size()) {
+		return false
+	}
+
+	if err := c.msgHandler.SendStatRequest(name, true); err != nil {
+		return false
+	}
+	ok, _, _, serverCheck, _, _ := c.msgHandler.ReceiveStatResponse()
+	if !ok {
+		return false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	md5 := md5.New()
+	if _, err := io.Copy(md5, file); err != nil {
+		return false
+	}
+	return bytes.Equal(serverCheck, md5.Sum(nil))
+}
+
+// GetTree retrieves every file stored under root, recreating the directory
+// structure locally. Files that already exist locally are skipped; downloads
+// only appear under their real name once complete, so an interrupted
+// retrieval can simply be run again.
+func (c *Client) GetTree(root string, report func(TreeResult)) (TreeSummary, error) {
+	if err := c.begin(); err != nil {
+		return TreeSummary{}, err
+	}
+	defer c.end()
+
+	var summary TreeSummary
+	entries, err := c.List(root, true)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, entry := range entries {
+		path := filepath.FromSlash(entry.Name)
+		if !filepath.IsLocal(path) {
+			// Only ever write below the current directory, whatever the
+			// server sends
+			result := TreeResult{Name: entry.Name, Err: errors.New("not a relative path below the current directory")}
+			summary.add(result)
+			report(result)
+			continue
+		}
+		if entry.IsDir {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		result := TreeResult{Name: path}
+		if _, err := os.Lstat(path); err == nil {
+			result.Skipped = true
+		} else {
+			result.Err = c.GetFile(context.Background(), entry.Name, path)
+		}
+		summary.add(result)
+		report(result)
+	}
+	return summary, nil
+}
@@ -0,0 +1,767 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"file-transfer/client"
+	"file-transfer/encryption"
+	"file-transfer/util"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// loadKeys returns the key to use for end-to-end encryption: the key file if
+// one was given, otherwise the passphrase in $FT_PASSPHRASE, otherwise nil.
+func loadKeys(keyFile string) (*encryption.KeySource, error) {
+	if keyFile != "" {
+		return encryption.KeyFromFile(keyFile)
+	}
+	if passphrase := os.Getenv("FT_PASSPHRASE"); passphrase != "" {
+		return encryption.KeyFromPassphrase(passphrase), nil
+	}
+	return nil, nil
+}
+
+// jsonOutput makes commands print their results as JSON, one object per
+// line, for scripts. Human-readable messages still go to standard error.
+var jsonOutput bool
+
+func printJSON(v interface{}) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Println(string(out))
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// A transferResult reports a put or get in JSON.
+type transferResult struct {
+	Command string `json:"command"`
+	Local   string `json:"local"`
+	Remote  string `json:"remote"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// noClobber skips puts and gets whose destination exists instead of failing
+// them.
+var noClobber bool
+
+// remoteName is the name the local file at path is stored under: name if
+// given, with the file's base name added if name ends in a slash, or else
+// the default client.RemoteName gives.
+func remoteName(path string, name string) string {
+	switch {
+	case strings.HasSuffix(name, "/"):
+		return name + filepath.Base(path)
+	case name != "":
+		return name
+	}
+	return client.RemoteName(path)
+}
+
+// localPath is where the stored file fileName is saved: at path if given, or
+// in it if it is a directory. Otherwise the file keeps its stored name,
+// relative to the current directory.
+func localPath(fileName string, path string) string {
+	if path == "" {
+		return filepath.FromSlash(fileName)
+	}
+	if info, err := os.Stat(path); strings.HasSuffix(path, "/") || err == nil && info.IsDir() {
+		return filepath.Join(path, filepath.Base(filepath.FromSlash(fileName)))
+	}
+	return path
+}
+
+func put(c *client.Client, path string, fileName string) int {
+	if noClobber {
+		if _, err := c.Stat(fileName, false); err == nil {
+			return skipped("put", path, fileName)
+		}
+	}
+	if !jsonOutput {
+		if fileName == filepath.ToSlash(path) {
+			fmt.Println("PUT", path)
+		} else {
+			fmt.Println("PUT", path, "as", fileName)
+		}
+	}
+	// c watches the command's context, so none is needed per transfer
+	err := c.PutFile(context.Background(), path, fileName)
+	if jsonOutput {
+		printJSON(transferResult{Command: "put", Local: path, Remote: fileName, OK: err == nil, Error: errorString(err)})
+	} else if err == nil {
+		fmt.Println("Storage complete!")
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+func get(c *client.Client, fileName string, path string) int {
+	if _, err := os.Lstat(path); err == nil && noClobber {
+		return skipped("get", path, fileName)
+	}
+	if !jsonOutput {
+		if path == filepath.FromSlash(fileName) {
+			fmt.Println("GET", fileName)
+		} else {
+			fmt.Println("GET", fileName, "to", path)
+		}
+	}
+	err := c.GetFile(context.Background(), fileName, path)
+	if jsonOutput {
+		printJSON(transferResult{Command: "get", Local: path, Remote: fileName, OK: err == nil, Error: errorString(err)})
+	}
+	if err != nil {
+		log.Println("FAILED to retrieve file:", err)
+		return 1
+	}
+	if !jsonOutput {
+		log.Println("Successfully retrieved file.")
+	}
+	return 0
+}
+
+// skipped reports a transfer left out because of noClobber.
+func skipped(command string, path string, fileName string) int {
+	if jsonOutput {
+		printJSON(transferResult{Command: command, Local: path, Remote: fileName, OK: true, Skipped: true})
+	} else if command == "put" {
+		fmt.Println("Skipping", path+":", fileName, "is already stored")
+	} else {
+		fmt.Println("Skipping", fileName+":", path, "already exists")
+	}
+	return 0
+}
+
+func putStdin(c *client.Client, fileName string) int {
+	if noClobber {
+		if _, err := c.Stat(fileName, false); err == nil {
+			return skipped("put", "-", fileName)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "PUT", fileName, "from standard input")
+	err := c.Put(context.Background(), fileName, os.Stdin, -1)
+	if jsonOutput {
+		printJSON(transferResult{Command: "put", Local: "-", Remote: fileName, OK: err == nil, Error: errorString(err)})
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "Storage complete!")
+	return 0
+}
+
+// getStdout writes the file to standard output, so nothing else may be
+// printed there, not even JSON.
+func getStdout(c *client.Client, fileName string) int {
+	out := bufio.NewWriter(os.Stdout)
+	err := c.Get(context.Background(), fileName, out)
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		log.Println("FAILED to retrieve file:", err)
+		return 1
+	}
+	log.Println("Successfully retrieved file.")
+	return 0
+}
+
+func printResult(result client.TreeResult) {
+	switch {
+	case jsonOutput:
+		printJSON(struct {
+			Name    string `json:"name"`
+			Skipped bool   `json:"skipped"`
+			Error   string `json:"error,omitempty"`
+		}{result.Name, result.Skipped, errorString(result.Err)})
+	case result.Err != nil:
+		fmt.Println("FAILED ", result.Name+":", result.Err)
+	case result.Skipped:
+		fmt.Println("skipped", result.Name)
+	default:
+		fmt.Println("ok     ", result.Name)
+	}
+}
+
+// transferTree runs a recursive put or get and prints a line per file plus
+// a summary.
+func transferTree(transfer func(string, func(client.TreeResult)) (client.TreeSummary, error), root string) int {
+	summary, err := transfer(root, printResult)
+	if jsonOutput {
+		printJSON(struct {
+			Transferred int    `json:"transferred"`
+			Skipped     int    `json:"skipped"`
+			Failed      int    `json:"failed"`
+			Error       string `json:"error,omitempty"`
+		}{summary.Transferred, summary.Skipped, summary.Failed, errorString(err)})
+	} else {
+		fmt.Printf("%d transferred, %d skipped, %d failed\n", summary.Transferred, summary.Skipped, summary.Failed)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	if summary.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runCommand(c *client.Client, line string) int {
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 3 {
+		log.Println("Invalid command:", line)
+		return 1
+	}
+	other := ""
+	if len(fields) == 3 {
+		other = fields[2]
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "put":
+		return put(c, fields[1], remoteName(fields[1], other))
+	case "get":
+		return get(c, fields[1], localPath(fields[1], other))
+	default:
+		log.Println("Invalid action", fields[0])
+		return 1
+	}
+}
+
+// batch runs one "put file [name]" or "get name [file]" command per line of
+// input over a single connection and returns the number of commands that failed. With
+// parallel > 1 the connection is multiplexed and that many commands run at
+// once, each on its own stream.
+func batch(c *client.Client, input *os.File, parallel int) int {
+	commands := make(chan string)
+	results := make(chan int)
+	workers := parallel
+
+	if parallel <= 1 {
+		workers = 1
+		go func() {
+			failed := 0
+			for line := range commands {
+				failed += runCommand(c, line)
+			}
+			results <- failed
+		}()
+	} else {
+		if err := c.Multiplex(); err != nil {
+			log.Println(err)
+			return 1
+		}
+		for i := 0; i < parallel; i++ {
+			go func() {
+				failed := 0
+				stream, err := c.Stream()
+				if err != nil {
+					log.Println(err)
+				}
+				for line := range commands {
+					if err != nil {
+						failed++
+						continue
+					}
+					failed += runCommand(stream, line)
+				}
+				if stream != nil {
+					stream.Close()
+				}
+				results <- failed
+			}()
+		}
+	}
+
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			commands <- line
+		}
+	}
+	close(commands)
+
+	failed := 0
+	if err := scanner.Err(); err != nil {
+		log.Println(err)
+		failed++
+	}
+	for i := 0; i < workers; i++ {
+		failed += <-results
+	}
+	return failed
+}
+
+// An invocation is what commands need to know about how they were run.
+type invocation struct {
+	host    string
+	profile map[string]string
+
+	// Done once the user interrupts, which aborts transfers cleanly instead
+	// of leaving them half done
+	ctx context.Context
+}
+
+// parse parses the arguments of a command, with the settings of the profile
+// as defaults.
+func (inv *invocation) parse(flags *flag.FlagSet, args []string) {
+	flags.BoolVar(&jsonOutput, "json", jsonOutput, "print results as JSON, one object per line")
+	for name, value := range inv.profile {
+		if flags.Lookup(name) == nil {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			log.Fatalf("Invalid %s in profile: %v", name, err)
+		}
+	}
+	flags.Parse(args)
+}
+
+// connectionFlags are the flags of every command that talks to the server.
+type connectionFlags struct {
+	connectTimeout  *time.Duration
+	stallTimeout    *time.Duration
+	keepalive       *time.Duration
+	keepaliveMisses *int
+	limitRate       int64
+}
+
+func addConnectionFlags(flags *flag.FlagSet) *connectionFlags {
+	f := &connectionFlags{
+		connectTimeout:  flags.Duration("connect-timeout", 30*time.Second, "give up connecting to the server after this long"),
+		stallTimeout:    flags.Duration("stall-timeout", time.Minute, "abort transfers that make no progress for this long (0 disables)"),
+		keepalive:       flags.Duration("keepalive", 30*time.Second, "ping the server this often while idle (0 disables)"),
+		keepaliveMisses: flags.Int("keepalive-misses", 3, "reconnect after this many pings in a row go unanswered"),
+	}
+	flags.Func("limit-rate", "limit the bandwidth of transfers, e.g. 500K or 10M (bytes per second)", func(s string) (err error) {
+		f.limitRate, err = util.ParseRate(s)
+		return err
+	})
+	return f
+}
+
+func (inv *invocation) connect(f *connectionFlags) *client.Client {
+	if inv.host == "" {
+		log.Fatalln("No server given: use -server, a profile, or put host:port before the command")
+	}
+	c, err := client.DialTimeout(inv.host, *f.connectTimeout, *f.stallTimeout)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	c.Logger = log.Default()
+	c.Watch(inv.ctx)
+	c.StartKeepalive(*f.keepalive, *f.keepaliveMisses)
+	if f.limitRate > 0 {
+		c.Limit = util.NewRateLimiter(f.limitRate)
+	}
+	return c
+}
+
+// transfer runs put, get and batch.
+func transfer(action string) func(*invocation, []string) int {
+	return func(inv *invocation, args []string) int {
+		flags := flag.NewFlagSet(action, flag.ExitOnError)
+		encrypt := false
+		if action != "get" {
+			flags.BoolVar(&encrypt, "encrypt", false, "encrypt files before they leave this machine")
+		}
+		keyFile := flags.String("keyfile", "", "32-byte key for end-to-end encryption (default: passphrase from $FT_PASSPHRASE)")
+		conn := addConnectionFlags(flags)
+		connections := flags.Int("connections", 1, "split large files into this many ranges and transfer them over separate connections")
+		recursive := false
+		asTar := false
+		if action != "batch" {
+			flags.BoolVar(&recursive, "r", false, "transfer a whole directory tree")
+			flags.BoolVar(&asTar, "tar", false, "transfer a whole directory tree as a single tar archive (get saves it as <dir>.tar)")
+		}
+		var otherName string
+		switch action {
+		case "put":
+			flags.StringVar(&otherName, "as", "", "store the file under this name (same as giving it after the file)")
+		case "get":
+			flags.StringVar(&otherName, "o", "", "save the file to this path or directory (same as giving it after the name)")
+		}
+		force := flags.Bool("force", false, "replace files that already exist at the destination")
+		flags.BoolVar(&noClobber, "no-clobber", false, "skip files that already exist at the destination")
+		preserve := flags.Bool("preserve", false, "keep the permission bits and modification time of files")
+		xattrs := flags.Bool("xattrs", false, "keep user extended attributes as well (implies --preserve)")
+		progressInterval := flags.Duration("progress-interval", 5*time.Second, "how often to print progress when standard error is not a terminal (0: only summaries)")
+		noProgress := flags.Bool("no-progress", false, "do not report progress or print summaries")
+		parallel := 1
+		if action == "batch" {
+			flags.IntVar(&parallel, "parallel", 1, "run this many commands at once over one multiplexed connection")
+		}
+		inv.parse(flags, args)
+		if action != "batch" && flags.NArg() < 1 {
+			log.Fatalln("Missing file name")
+		}
+		if flags.NArg() > 2 || action == "batch" && flags.NArg() > 1 {
+			log.Fatalln("Too many arguments")
+		}
+		if flags.NArg() == 2 {
+			if otherName != "" {
+				log.Fatalln("Give the destination either as an argument or with -as or -o")
+			}
+			otherName = flags.Arg(1)
+		}
+		if otherName != "" && (recursive || asTar) {
+			log.Fatalln("Directory transfers keep their names")
+		}
+		if *force && noClobber {
+			log.Fatalln("--force and --no-clobber exclude each other")
+		}
+
+		keys, err := loadKeys(*keyFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if encrypt && keys == nil {
+			log.Fatalln("--encrypt needs --keyfile or $FT_PASSPHRASE")
+		}
+
+		fileName := flags.Arg(0)
+
+		// "put - name" stores standard input as name, and "get name -" writes to
+		// standard output instead of a local file.
+		fromStdin := action == "put" && fileName == "-"
+		toStdout := action == "get" && otherName == "-"
+		if fromStdin && (otherName == "" || strings.HasSuffix(otherName, "/")) {
+			log.Fatalln("Missing name to store standard input as")
+		}
+
+		c := inv.connect(conn)
+		c.Keys = keys
+		c.Encrypt = encrypt
+		c.Connections = *connections
+		c.Preserve = *preserve
+		c.Xattrs = *xattrs
+		c.Overwrite = *force
+		if !*noProgress {
+			newReporter(*progressInterval).attach(c)
+		}
+
+		status := 0
+		switch {
+		case fromStdin:
+			status = putStdin(c, otherName)
+		case toStdout:
+			status = getStdout(c, fileName)
+		case action == "put" && asTar:
+			status = transferTree(c.PutTar, fileName)
+		case action == "get" && asTar:
+			base := filepath.Base(filepath.FromSlash(fileName))
+			if base == "." || base == ".." || base == string(filepath.Separator) {
+				log.Fatalln("Name the directory to archive")
+			}
+			archive := base + ".tar"
+			status = transferTree(func(root string, report func(client.TreeResult)) (client.TreeSummary, error) {
+				summary, err := c.GetTar(root, archive, report)
+				if err == nil {
+					log.Println("Successfully retrieved archive.")
+				}
+				return summary, err
+			}, fileName)
+		case action == "put" && recursive:
+			status = transferTree(c.PutTree, fileName)
+		case action == "get" && recursive:
+			status = transferTree(c.GetTree, fileName)
+		case action == "put":
+			status = put(c, fileName, remoteName(fileName, otherName))
+		case action == "get":
+			status = get(c, fileName, localPath(fileName, otherName))
+		case action == "batch":
+			// Commands come from stdin, or from the file named in place of a file name
+			input := os.Stdin
+			if fileName != "" {
+				if input, err = os.Open(fileName); err != nil {
+					log.Fatalln(err)
+				}
+			}
+			if status = batch(c, input, parallel); status > 0 {
+				log.Println(status, "commands failed")
+				status = 1
+			}
+		}
+
+		c.Close()
+		return status
+	}
+}
+
+// A listEntry is a line of ls output in JSON.
+type listEntry struct {
+	Name  string `json:"name"`
+	Size  uint64 `json:"size"`
+	IsDir bool   `json:"dir"`
+}
+
+func list(inv *invocation, args []string) int {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	recursive := flags.Bool("r", false, "list subdirectories as well")
+	conn := addConnectionFlags(flags)
+	inv.parse(flags, args)
+
+	c := inv.connect(conn)
+	entries, err := c.List(flags.Arg(0), *recursive)
+	c.Close()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	for _, entry := range entries {
+		if jsonOutput {
+			printJSON(listEntry{entry.Name, entry.Size, entry.IsDir})
+		} else if entry.IsDir {
+			fmt.Printf("%12s  %s/\n", "-", entry.Name)
+		} else {
+			fmt.Printf("%12d  %s\n", entry.Size, entry.Name)
+		}
+	}
+	return 0
+}
+
+// A statResult is the output of stat in JSON.
+type statResult struct {
+	Name      string `json:"name"`
+	Size      uint64 `json:"size"`
+	Mode      string `json:"mode,omitempty"`
+	Modified  string `json:"modified,omitempty"`
+	MD5       string `json:"md5,omitempty"`
+	Encrypted bool   `json:"encrypted,omitempty"`
+}
+
+func stat(inv *invocation, args []string) int {
+	flags := flag.NewFlagSet("stat", flag.ExitOnError)
+	checksum := flags.Bool("checksum", false, "show the MD5 checksum of the contents, which the server may have to compute")
+	conn := addConnectionFlags(flags)
+	inv.parse(flags, args)
+	if flags.NArg() < 1 {
+		log.Fatalln("Missing file name")
+	}
+
+	c := inv.connect(conn)
+	status := 0
+	for _, fileName := range flags.Args() {
+		info, err := c.Stat(fileName, *checksum)
+		if err != nil {
+			log.Println(err)
+			status = 1
+			continue
+		}
+		result := statResult{Name: info.Name, Size: info.Size, Encrypted: info.Encrypted}
+		if info.Metadata != nil {
+			result.Mode = util.SafeMode(info.Metadata.Mode).String()
+			result.Modified = time.Unix(0, info.Metadata.Mtime).Format(time.RFC3339)
+		}
+		if info.Checksum != nil {
+			result.MD5 = hex.EncodeToString(info.Checksum)
+		}
+		if jsonOutput {
+			printJSON(result)
+			continue
+		}
+		fmt.Println("Name:    ", result.Name)
+		fmt.Println("Size:    ", result.Size)
+		if result.Mode != "" {
+			fmt.Println("Mode:    ", result.Mode)
+			fmt.Println("Modified:", result.Modified)
+		}
+		if result.MD5 != "" {
+			fmt.Println("MD5:     ", result.MD5)
+		}
+		if result.Encrypted {
+			fmt.Println("Encrypted end-to-end")
+		}
+	}
+	c.Close()
+	return status
+}
+
+// A changeResult reports an rm or mv in JSON.
+type changeResult struct {
+	Command string `json:"command"`
+	Remote  string `json:"remote"`
+	To      string `json:"to,omitempty"`
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+func remove(inv *invocation, args []string) int {
+	flags := flag.NewFlagSet("rm", flag.ExitOnError)
+	conn := addConnectionFlags(flags)
+	inv.parse(flags, args)
+	if flags.NArg() < 1 {
+		log.Fatalln("Missing file name")
+	}
+
+	c := inv.connect(conn)
+	status := 0
+	for _, fileName := range flags.Args() {
+		err := c.Delete(fileName)
+		if jsonOutput {
+			printJSON(changeResult{Command: "rm", Remote: fileName, OK: err == nil, Error: errorString(err)})
+		} else if err == nil {
+			fmt.Println("removed", fileName)
+		}
+		if err != nil {
+			log.Println(err)
+			status = 1
+		}
+	}
+	c.Close()
+	return status
+}
+
+func move(inv *invocation, args []string) int {
+	flags := flag.NewFlagSet("mv", flag.ExitOnError)
+	conn := addConnectionFlags(flags)
+	inv.parse(flags, args)
+	if flags.NArg() != 2 {
+		log.Fatalln("mv needs the current and the new name")
+	}
+	from, to := flags.Arg(0), flags.Arg(1)
+
+	c := inv.connect(conn)
+	err := c.Rename(from, to)
+	c.Close()
+	if jsonOutput {
+		printJSON(changeResult{Command: "mv", Remote: from, To: to, OK: err == nil, Error: errorString(err)})
+	} else if err == nil {
+		fmt.Println("renamed", from, "to", to)
+	}
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// setRate changes the bandwidth limits of a server running on this machine.
+// Limits that are not given stay as they are.
+func setRate(inv *invocation, args []string) int {
+	flags := flag.NewFlagSet("set-rate", flag.ExitOnError)
+	rates := map[string]*int64{}
+	for _, name := range []string{"global", "per-user", "per-connection"} {
+		rate := new(int64)
+		*rate = -1
+		rates[name] = rate
+		flags.Func(name, "new "+name+" limit, e.g. 10M (0 removes it)", func(s string) (err error) {
+			*rate, err = util.ParseRate(s)
+			return err
+		})
+	}
+	conn := addConnectionFlags(flags)
+	inv.parse(flags, args)
+
+	c := inv.connect(conn)
+	limits, err := c.SetRateLimits(*rates["global"], *rates["per-user"], *rates["per-connection"])
+	c.Close()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	if jsonOutput {
+		printJSON(struct {
+			Limits string `json:"limits"`
+		}{limits})
+	} else {
+		fmt.Println(limits)
+	}
+	return 0
+}
+
+type command struct {
+	args    string
+	summary string
+	run     func(inv *invocation, args []string) int
+}
+
+var commands map[string]*command
+
+// Kept in order for the usage message.
+var commandNames = []string{"put", "get", "ls", "stat", "rm", "mv", "batch", "set-rate"}
+
+func init() {
+	commands = map[string]*command{
+		"put":      {"[flags] file [name | dir/] | - name", "store a file, or standard input as name", transfer("put")},
+		"get":      {"[flags] name [file | download-dir | -]", "retrieve a file, or write it to standard output", transfer("get")},
+		"ls":       {"[-r] [path]", "list stored files", list},
+		"stat":     {"[-checksum] name...", "show the size, mode and modification time of stored files", stat},
+		"rm":       {"name...", "delete stored files or empty directories", remove},
+		"mv":       {"name new-name", "rename a stored file or directory", move},
+		"batch":    {"[flags] [file]", "run \"put file [name]\" and \"get name [file]\" lines read from file or standard input", transfer("batch")},
+		"set-rate": {"[-global rate] [-per-user rate] [-per-connection rate]", "change the bandwidth limits of a server on this machine", setRate},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [-server host:port] [-profile name] [-json] command [flags] [arguments]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s host:port command [flags] [arguments]\n\nCommands:\n", os.Args[0])
+	for _, name := range commandNames {
+		c := commands[name]
+		fmt.Fprintf(os.Stderr, "  %s %s\n        %s\n", name, c.args, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun \"%s command -h\" for the flags of a command.\n\nGlobal flags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	server := flag.String("server", "", "host:port of the server (default: from the profile)")
+	profileName := flag.String("profile", "", "use the settings of this profile (default: the default profile, if any)")
+	flag.BoolVar(&jsonOutput, "json", false, "print results as JSON, one object per line")
+	flag.Parse()
+
+	// The server can also come first, as in earlier versions
+	args := flag.Args()
+	if len(args) > 0 && commands[strings.ToLower(args[0])] == nil && *server == "" {
+		*server, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(1)
+	}
+	cmd := commands[strings.ToLower(args[0])]
+	if cmd == nil {
+		log.Fatalln("Unknown command", args[0])
+	}
+
+	profile, err := loadProfile(*profileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	inv := &invocation{host: *server, profile: profile}
+	if inv.host == "" {
+		inv.host = profile["server"]
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	inv.ctx = ctx
+	status := cmd.run(inv, args[1:])
+	stop()
+	os.Exit(status)
+}
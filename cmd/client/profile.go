@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Profiles are kept in file-transfer/profiles under the user's config
+// directory (usually ~/.config), as sections of "key = value" lines:
+//
+//	[default]
+//	server = files.example.com:9000
+//
+//	[backup]
+//	server = backup.example.com:9000
+//	limit-rate = 5M
+//	connections = 4
+//
+// Keys are "server" and the names of command flags, which the command line
+// overrides; settings for flags a command does not have are ignored. The
+// default profile applies when no other is named.
+func profilesFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "file-transfer", "profiles"), nil
+}
+
+// loadProfile returns the settings of the named profile, or of the default
+// profile if name is empty. Only a named profile has to exist.
+func loadProfile(name string) (map[string]string, error) {
+	path, err := profilesFile()
+	if err != nil {
+		if name == "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && name == "" {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	wanted := name
+	if wanted == "" {
+		wanted = "default"
+	}
+	var settings map[string]string
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			if section == wanted && settings == nil {
+				settings = make(map[string]string)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: line %d: expected key = value", path, line)
+		}
+		if section != wanted {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("%s: line %d: invalid quoted value", path, line)
+			}
+		}
+		settings[strings.TrimSpace(key)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if settings == nil && name != "" {
+		return nil, fmt.Errorf("%s: no profile named %s", path, name)
+	}
+	return settings, nil
+}
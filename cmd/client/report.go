@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"file-transfer/client"
+	"file-transfer/util"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often the progress line is redrawn on a terminal.
+const redrawInterval = 200 * time.Millisecond
+
+// A reporter shows the progress of transfers on standard error: on a
+// terminal as a line redrawn in place, otherwise as a JSON object per line
+// every interval. Either way each transfer ends with a summary.
+type reporter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	tty      bool
+	interval time.Duration
+	drawn    bool // a progress line is on the screen
+}
+
+func newReporter(interval time.Duration) *reporter {
+	r := &reporter{out: os.Stderr, interval: interval}
+	if info, err := os.Stderr.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		r.tty = true
+		// Log messages must not end up in the middle of the progress line
+		log.SetOutput(r)
+	}
+	return r
+}
+
+// attach makes c report to r.
+func (r *reporter) attach(c *client.Client) {
+	if r.tty || r.interval > 0 {
+		c.Progress = r.progress
+		c.ProgressInterval = r.interval
+		if r.tty {
+			c.ProgressInterval = redrawInterval
+		}
+	}
+	c.Done = r.done
+}
+
+// Write prints p below any progress line.
+func (r *reporter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clear()
+	return r.out.Write(p)
+}
+
+func (r *reporter) clear() {
+	if r.drawn {
+		fmt.Fprint(r.out, "\r\x1b[K")
+		r.drawn = false
+	}
+}
+
+// A progressLine is a progress report in JSON. Total, Percent and ETA are
+// left out while the size of the transfer is not known.
+type progressLine struct {
+	Progress string   `json:"progress"`
+	Bytes    int64    `json:"bytes"`
+	Total    *int64   `json:"total,omitempty"`
+	Percent  *float64 `json:"percent,omitempty"`
+	Rate     int64    `json:"rate"`
+	ETA      *float64 `json:"eta,omitempty"`
+}
+
+func (r *reporter) progress(p client.Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.tty {
+		line := progressLine{Progress: p.Name, Bytes: p.Bytes, Rate: int64(p.Rate())}
+		if p.Total >= 0 {
+			percent := percentage(p)
+			line.Total, line.Percent = &p.Total, &percent
+			if remaining := p.Remaining(); remaining >= 0 {
+				eta := remaining.Seconds()
+				line.ETA = &eta
+			}
+		}
+		data, _ := json.Marshal(line)
+		fmt.Fprintln(r.out, string(data))
+		return
+	}
+
+	fields := []string{p.Name}
+	if p.Total >= 0 {
+		fields = append(fields,
+			fmt.Sprintf("%5.1f%%", percentage(p)),
+			util.FormatSize(p.Bytes)+"/"+util.FormatSize(p.Total))
+	} else {
+		fields = append(fields, util.FormatSize(p.Bytes))
+	}
+	fields = append(fields, util.FormatSize(int64(p.Rate()))+"/s")
+	if remaining := p.Remaining(); remaining >= 0 {
+		fields = append(fields, "ETA "+remaining.Round(time.Second).String())
+	}
+	fmt.Fprint(r.out, "\r"+strings.Join(fields, "  ")+"\x1b[K")
+	r.drawn = true
+}
+
+func percentage(p client.Progress) float64 {
+	if p.Total <= 0 {
+		return 100
+	}
+	return float64(p.Bytes) / float64(p.Total) * 100
+}
+
+// A transferSummary is the summary of a transfer in JSON.
+type transferSummary struct {
+	Summary  string  `json:"summary"`
+	Bytes    int64   `json:"bytes"`
+	Seconds  float64 `json:"seconds"`
+	Rate     int64   `json:"rate"`
+	Checksum string  `json:"checksum"`
+}
+
+func (r *reporter) done(stats client.TransferStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clear()
+	if !r.tty {
+		data, _ := json.Marshal(transferSummary{stats.Name, stats.Bytes, stats.Duration.Seconds(), int64(stats.Rate()), stats.Checksum})
+		fmt.Fprintln(r.out, string(data))
+		return
+	}
+	fmt.Fprintf(r.out, "%s: %s in %s (%s/s), checksum %s\n", stats.Name, util.FormatSize(stats.Bytes),
+		stats.Duration.Round(time.Millisecond), util.FormatSize(int64(stats.Rate())), stats.Checksum)
+}
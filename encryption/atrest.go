@@ -0,0 +1,206 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"file-transfer/util"
+	"io"
+	"os"
+)
+
+// Files encrypted at rest by the server start with a fixed-size header:
+//
+//	magic "FTS1" | master key ID (8 bytes) | nonce (12 bytes)
+//	| data key sealed with the master key (48 bytes) | sealed chunk stream
+//
+// Every file has its own random data key. Rotating the master key only
+// rewrites the header, never the body.
+var atRestMagic = []byte("FTS1")
+
+const (
+	keyIDSize        = 8
+	wrappedKeySize   = 12 + 32 + Overhead
+	AtRestHeaderSize = 4 + keyIDSize + wrappedKeySize
+)
+
+var ErrUnknownMasterKey = errors.New("file was sealed with an unknown master key")
+
+type MasterKey struct {
+	ID   []byte
+	aead cipher.AEAD
+}
+
+// LoadMasterKey reads a 32-byte master key, either raw or hex encoded.
+func LoadMasterKey(path string) (*MasterKey, error) {
+	key, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &MasterKey{ID: sum[:keyIDSize], aead: aead}, nil
+}
+
+func (mk *MasterKey) wrap(dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, 12)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	ad := append(append([]byte{}, atRestMagic...), mk.ID...)
+	header := append(append([]byte{}, ad...), nonce...)
+	return mk.aead.Seal(header, nonce, dataKey, ad), nil
+}
+
+func (mk *MasterKey) unwrap(header []byte) ([]byte, error) {
+	nonce := header[len(atRestMagic)+keyIDSize : len(atRestMagic)+keyIDSize+12]
+	sealed := header[len(atRestMagic)+keyIDSize+12:]
+	dataKey, err := mk.aead.Open(nil, nonce, sealed, header[:len(atRestMagic)+keyIDSize])
+	if err != nil {
+		return nil, ErrTampered
+	}
+	return dataKey, nil
+}
+
+// SealedFileSize returns the on-disk size of a file of n bytes sealed at rest.
+func SealedFileSize(n int64) int64 {
+	return AtRestHeaderSize + SealedSize(n)
+}
+
+// IsSealedFile reports whether header is a valid header of a file sealed at
+// rest. Whether a file is sealed is recorded elsewhere; this only checks
+// files that are supposed to be.
+func IsSealedFile(header []byte) bool {
+	return len(header) >= AtRestHeaderSize && bytes.Equal(header[:len(atRestMagic)], atRestMagic)
+}
+
+// SealFile writes a header with a fresh data key to w and returns a writer
+// for the file contents.
+func SealFile(w io.Writer, mk *MasterKey) (*ChunkWriter, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	header, err := mk.wrap(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return NewChunkWriter(w, dataKey)
+}
+
+func findKey(header []byte, keys []*MasterKey) *MasterKey {
+	id := header[len(atRestMagic) : len(atRestMagic)+keyIDSize]
+	for _, mk := range keys {
+		if bytes.Equal(mk.ID, id) {
+			return mk
+		}
+	}
+	return nil
+}
+
+// OpenFile reads the header of a sealed file from r and returns a reader for
+// its contents. Any of keys may have been used to seal it.
+func OpenFile(r io.Reader, keys ...*MasterKey) (*ChunkReader, error) {
+	header := make([]byte, AtRestHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if !IsSealedFile(header) {
+		return nil, errors.New("file is not sealed")
+	}
+	mk := findKey(header, keys)
+	if mk == nil {
+		return nil, ErrUnknownMasterKey
+	}
+	dataKey, err := mk.unwrap(header)
+	if err != nil {
+		return nil, err
+	}
+	return NewChunkReader(r, dataKey)
+}
+
+// OpenFileAt is like OpenFile, but the returned reader starts at offset
+// bytes into the file contents. Only the chunks from there on are read.
+func OpenFileAt(r io.ReadSeeker, offset int64, keys ...*MasterKey) (*ChunkReader, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	header := make([]byte, AtRestHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if !IsSealedFile(header) {
+		return nil, errors.New("file is not sealed")
+	}
+	mk := findKey(header, keys)
+	if mk == nil {
+		return nil, ErrUnknownMasterKey
+	}
+	dataKey, err := mk.unwrap(header)
+	if err != nil {
+		return nil, err
+	}
+
+	chunk := offset / ChunkSize
+	if _, err := r.Seek(AtRestHeaderSize+chunk*(ChunkSize+Overhead), io.SeekStart); err != nil {
+		return nil, err
+	}
+	reader, err := NewChunkReader(r, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	reader.counter = uint64(chunk)
+	if _, err := io.CopyN(io.Discard, reader, offset%ChunkSize); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Rewrap replaces the header of file, which must be sealed and open for
+// writing, so that its data key is wrapped by to instead of whichever of from
+// it was sealed with. Files that already use to are left alone. It reports
+// whether the file was changed. The contents stay the same, and so do the
+// file's times, which keeps its saved checksum valid.
+func Rewrap(file *os.File, to *MasterKey, from ...*MasterKey) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	header := make([]byte, AtRestHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return false, err
+	}
+	if !IsSealedFile(header) {
+		return false, ErrTampered
+	}
+	if findKey(header, []*MasterKey{to}) != nil {
+		return false, nil
+	}
+	mk := findKey(header, from)
+	if mk == nil {
+		return false, ErrUnknownMasterKey
+	}
+	dataKey, err := mk.unwrap(header)
+	if err != nil {
+		return false, err
+	}
+	newHeader, err := to.wrap(dataKey)
+	if err != nil {
+		return false, err
+	}
+	if _, err := file.WriteAt(newHeader, 0); err != nil {
+		return false, err
+	}
+	if err := file.Sync(); err != nil {
+		return false, err
+	}
+	return true, os.Chtimes(file.Name(), util.AccessTime(info), info.ModTime())
+}
@@ -0,0 +1,90 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"file-transfer/util"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func masterKey(t *testing.T, fill byte) *MasterKey {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, bytes.Repeat([]byte{fill}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mk, err := LoadMasterKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mk
+}
+
+func TestRewrap(t *testing.T) {
+	oldKey, newKey, otherKey := masterKey(t, 1), masterKey(t, 2), masterKey(t, 3)
+	data := bytes.Repeat([]byte("sealed at rest "), 10000)
+
+	path := filepath.Join(t.TempDir(), "file")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w, err := SealFile(file, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	atime, mtime := time.Unix(1000000000, 0), time.Unix(1200000000, 0)
+	if err := os.Chtimes(path, atime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if changed, err := Rewrap(file, newKey, oldKey); err != nil || !changed {
+		t.Fatalf("Rewrap = %v, %v, want the file changed", changed, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(mtime) {
+		t.Errorf("modification time changed to %v", info.ModTime())
+	}
+	if runtime.GOOS == "linux" && !util.AccessTime(info).Equal(atime) {
+		t.Errorf("access time changed to %v", util.AccessTime(info))
+	}
+	if changed, err := Rewrap(file, newKey, oldKey); err != nil || changed {
+		t.Fatalf("second Rewrap = %v, %v, want the file left alone", changed, err)
+	}
+	if _, err := Rewrap(file, otherKey, oldKey); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Fatalf("Rewrap from a key the file no longer uses: %v, want ErrUnknownMasterKey", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFile(file, oldKey); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("opened with the old key: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenFile(file, oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("read back %d bytes (%v), want the %d sealed", len(got), err, len(data))
+	}
+}
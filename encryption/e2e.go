@@ -0,0 +1,223 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Client-side (end-to-end) encrypted files start with this header:
+//
+//	magic "FTE1" | kdf (1 byte) | salt (32 bytes) | metadata length (4 bytes)
+//	| sealed metadata | sealed chunk stream
+//
+// The per-file key is derived from the salt, so the server only ever sees
+// ciphertext and the salt.
+var magic = []byte("FTE1")
+
+const (
+	kdfKeyFile    = 1
+	kdfPassphrase = 2
+
+	saltSize   = 32
+	headerSize = 4 + 1 + saltSize + 4
+)
+
+// Parameters for scrypt, as recommended for interactive logins in 2017.
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// Metadata is stored encrypted alongside the file contents.
+type Metadata struct {
+	Name string
+	Size int64
+}
+
+func (m Metadata) marshal() []byte {
+	buf := make([]byte, 8, 8+len(m.Name))
+	binary.BigEndian.PutUint64(buf, uint64(m.Size))
+	return append(buf, m.Name...)
+}
+
+func unmarshalMetadata(buf []byte) (Metadata, error) {
+	if len(buf) < 8 {
+		return Metadata{}, ErrTampered
+	}
+	return Metadata{Size: int64(binary.BigEndian.Uint64(buf)), Name: string(buf[8:])}, nil
+}
+
+// A KeySource turns a per-file salt into a per-file key.
+type KeySource struct {
+	kdf    byte
+	secret []byte
+}
+
+// readKeyFile reads a 32-byte key, either raw or hex encoded.
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if decoded, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil {
+		data = decoded
+	}
+	if len(data) != 32 {
+		return nil, fmt.Errorf("%s: key must be 32 bytes (or 64 hex digits), got %d bytes", path, len(data))
+	}
+	return data, nil
+}
+
+func KeyFromFile(path string) (*KeySource, error) {
+	key, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySource{kdf: kdfKeyFile, secret: key}, nil
+}
+
+func KeyFromPassphrase(passphrase string) *KeySource {
+	return &KeySource{kdf: kdfPassphrase, secret: []byte(passphrase)}
+}
+
+func (k *KeySource) derive(kdf byte, salt []byte) ([]byte, error) {
+	if kdf != k.kdf {
+		if kdf == kdfPassphrase {
+			return nil, errors.New("file was encrypted with a passphrase, not a key file")
+		}
+		return nil, errors.New("file was encrypted with a key file, not a passphrase")
+	}
+
+	key := make([]byte, 32)
+	switch kdf {
+	case kdfKeyFile:
+		if _, err := io.ReadFull(hkdf.New(sha256.New, k.secret, salt, []byte("file-transfer e2e")), key); err != nil {
+			return nil, err
+		}
+	case kdfPassphrase:
+		return scrypt.Key(k.secret, salt, scryptN, scryptR, scryptP, 32)
+	}
+	return key, nil
+}
+
+// EncryptedSize returns how many bytes Encrypt writes for a file described
+// by meta, so the size can be announced before the transfer starts.
+func EncryptedSize(meta Metadata) int64 {
+	return headerSize + int64(len(meta.marshal())) + Overhead + SealedSize(meta.Size)
+}
+
+// The metadata is sealed with the same key as the contents, so it uses a
+// nonce outside the range of chunk counters.
+func metadataNonce() []byte {
+	return bytes.Repeat([]byte{0xff}, 12)
+}
+
+// Encrypt writes the header for a new encrypted file to w and returns a
+// writer for its contents. Closing the writer does not close w.
+func Encrypt(w io.Writer, keys *KeySource, meta Metadata) (*ChunkWriter, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := keys.derive(keys.kdf, salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, magic...)
+	header = append(header, keys.kdf)
+	header = append(header, salt...)
+	sealedMeta := aead.Seal(nil, metadataNonce(), meta.marshal(), header)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(sealedMeta)))
+	if _, err := w.Write(append(header, sealedMeta...)); err != nil {
+		return nil, err
+	}
+
+	return NewChunkWriter(w, key)
+}
+
+type Reader struct {
+	chunks    *ChunkReader
+	remaining int64
+}
+
+// Decrypt reads the header written by Encrypt and returns the decrypted
+// metadata and a reader for the contents. The file must have been encrypted
+// under name, so that one stored in place of another fails with ErrTampered.
+func Decrypt(r io.Reader, keys *KeySource, name string) (*Reader, Metadata, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, Metadata{}, err
+	}
+	if !bytes.Equal(header[:len(magic)], magic) {
+		return nil, Metadata{}, errors.New("not an encrypted file")
+	}
+	if keys == nil {
+		return nil, Metadata{}, errors.New("file is encrypted but no key was provided")
+	}
+
+	kdf := header[len(magic)]
+	salt := header[len(magic)+1 : len(magic)+1+saltSize]
+	metaLen := binary.BigEndian.Uint32(header[headerSize-4:])
+	if metaLen > 64*1024 {
+		return nil, Metadata{}, ErrTampered
+	}
+
+	key, err := keys.derive(kdf, salt)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	sealedMeta := make([]byte, metaLen)
+	if _, err := io.ReadFull(r, sealedMeta); err != nil {
+		return nil, Metadata{}, err
+	}
+	plainMeta, err := aead.Open(nil, metadataNonce(), sealedMeta, header[:headerSize-4])
+	if err != nil {
+		return nil, Metadata{}, ErrTampered
+	}
+	meta, err := unmarshalMetadata(plainMeta)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	if meta.Name != name {
+		return nil, Metadata{}, ErrTampered
+	}
+
+	chunks, err := NewChunkReader(r, key)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	return &Reader{chunks: chunks, remaining: meta.Size}, meta, nil
+}
+
+// Read also checks the decrypted length against the size recorded in the
+// metadata.
+func (d *Reader) Read(p []byte) (int, error) {
+	n, err := d.chunks.Read(p)
+	d.remaining -= int64(n)
+	if d.remaining < 0 || (err == io.EOF && d.remaining != 0) {
+		return n, ErrTampered
+	}
+	return n, err
+}
@@ -0,0 +1,160 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Plaintext is split into chunks of ChunkSize bytes, each sealed on its own
+// so the stream can be produced and verified without buffering the file.
+const ChunkSize = 64 * 1024
+
+var ErrTampered = errors.New("encrypted data failed authentication")
+
+// SealedSize returns the number of bytes a ChunkWriter produces for n bytes
+// of plaintext. Even an empty stream carries one (final) chunk.
+func SealedSize(n int64) int64 {
+	chunks := (n + ChunkSize - 1) / ChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	return n + chunks*Overhead
+}
+
+// PlainSize is the inverse of SealedSize.
+func PlainSize(sealed int64) int64 {
+	chunks := (sealed + ChunkSize + Overhead - 1) / (ChunkSize + Overhead)
+	if chunks == 0 {
+		chunks = 1
+	}
+	return sealed - chunks*Overhead
+}
+
+// Overhead is the number of bytes each sealed chunk adds.
+const Overhead = 16
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Nonces are a big-endian chunk counter. Every key is used for exactly one
+// stream, so a counter never repeats under the same key. The final chunk is
+// marked in the additional data, which makes truncation detectable.
+func chunkNonce(counter uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
+
+func chunkAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type ChunkWriter struct {
+	aead    cipher.AEAD
+	w       io.Writer
+	buf     []byte
+	counter uint64
+}
+
+// NewChunkWriter seals everything written to it with AES-256-GCM under key.
+// Close must be called to emit the final chunk.
+func NewChunkWriter(w io.Writer, key []byte) (*ChunkWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkWriter{aead: aead, w: w, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+func (c *ChunkWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data shows up, since the
+		// last chunk of the stream has to be sealed as final.
+		if len(c.buf) == ChunkSize {
+			if err := c.seal(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(c.buf[len(c.buf):ChunkSize], p)
+		c.buf = c.buf[:len(c.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (c *ChunkWriter) seal(final bool) error {
+	sealed := c.aead.Seal(nil, chunkNonce(c.counter), c.buf, chunkAD(final))
+	c.counter++
+	c.buf = c.buf[:0]
+	_, err := c.w.Write(sealed)
+	return err
+}
+
+func (c *ChunkWriter) Close() error {
+	return c.seal(true)
+}
+
+type ChunkReader struct {
+	aead    cipher.AEAD
+	r       *bufio.Reader
+	counter uint64
+	plain   []byte
+	done    bool
+}
+
+// NewChunkReader opens a stream produced by a ChunkWriter. Read returns
+// ErrTampered if any chunk fails to authenticate or the stream was cut short.
+func NewChunkReader(r io.Reader, key []byte) (*ChunkReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkReader{aead: aead, r: bufio.NewReaderSize(r, ChunkSize+Overhead)}, nil
+}
+
+func (c *ChunkReader) Read(p []byte) (int, error) {
+	for len(c.plain) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.plain)
+	c.plain = c.plain[n:]
+	return n, nil
+}
+
+func (c *ChunkReader) next() error {
+	sealed := make([]byte, ChunkSize+Overhead)
+	n, err := io.ReadFull(c.r, sealed)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		c.done = true
+	} else if err != nil {
+		return err
+	} else if _, err := c.r.Peek(1); err == io.EOF {
+		c.done = true
+	}
+
+	plain, err := c.aead.Open(sealed[:0], chunkNonce(c.counter), sealed[:n], chunkAD(c.done))
+	if err != nil {
+		return ErrTampered
+	}
+	c.counter++
+	c.plain = plain
+	return nil
+}
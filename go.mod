@@ -1,5 +1,9 @@
 module file-transfer
 
-go 1.19
+go 1.21
 
-require google.golang.org/protobuf v1.28.1
+require (
+	golang.org/x/crypto v0.17.0
+	golang.org/x/sys v0.15.0
+	google.golang.org/protobuf v1.28.1
+)
@@ -0,0 +1,88 @@
+package messages
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Data of unknown length is sent in chunks, each preceded by its length as
+// a 4-byte little-endian integer. A chunk of length zero marks the end.
+
+type ChunkedWriter struct {
+	w io.Writer
+}
+
+func NewChunkedWriter(w io.Writer) *ChunkedWriter {
+	return &ChunkedWriter{w: w}
+}
+
+func (c *ChunkedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := len(p)
+		if n > MaxMessageSize {
+			n = MaxMessageSize
+		}
+		prefix := make([]byte, 4)
+		binary.LittleEndian.PutUint32(prefix, uint32(n))
+		if _, err := c.w.Write(prefix); err != nil {
+			return written, err
+		}
+		if _, err := c.w.Write(p[:n]); err != nil {
+			return written, err
+		}
+		written += n
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close writes the end marker; it does not close the underlying writer.
+func (c *ChunkedWriter) Close() error {
+	_, err := c.w.Write(make([]byte, 4))
+	return err
+}
+
+type ChunkedReader struct {
+	r         io.Reader
+	remaining uint32
+	done      bool
+}
+
+func NewChunkedReader(r io.Reader) *ChunkedReader {
+	return &ChunkedReader{r: r}
+}
+
+// Read returns io.EOF once the end marker has been read. The connection
+// ending before that is io.ErrUnexpectedEOF.
+func (c *ChunkedReader) Read(p []byte) (int, error) {
+	if c.done {
+		return 0, io.EOF
+	}
+	if c.remaining == 0 {
+		prefix := make([]byte, 4)
+		if _, err := io.ReadFull(c.r, prefix); err != nil {
+			return 0, unexpected(err)
+		}
+		c.remaining = binary.LittleEndian.Uint32(prefix)
+		if c.remaining == 0 {
+			c.done = true
+			return 0, io.EOF
+		}
+	}
+
+	if uint32(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.r.Read(p)
+	c.remaining -= uint32(n)
+	return n, unexpected(err)
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package messages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// ErrStalled means raw data stopped flowing for longer than the stall
+// timeout.
+var ErrStalled = errors.New("transfer stalled")
+
+// Deadlines apply to whole calls, so files sent with ReadFrom go in pieces
+// that each have to make it within the stall timeout.
+const readFromPiece = 1024 * 1024
+
+// SetReadDeadline bounds how long the next messages may take to arrive; the
+// zero time removes the limit. Raw data is bounded by the stall timeout
+// instead.
+func (m *MessageHandler) SetReadDeadline(t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readDeadline = t
+	return m.conn.SetReadDeadline(t)
+}
+
+// SetStallTimeout makes reading raw data, and all writes, fail with
+// ErrStalled once they have made no progress for d. Zero disables it.
+// Waiting for messages is not affected, as the peer may be busy working out
+// the answer.
+func (m *MessageHandler) SetStallTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stall = d
+}
+
+// Watch aborts any I/O in progress, and fails all further I/O, once ctx is
+// done. The returned function stops watching.
+func (m *MessageHandler) Watch(ctx context.Context) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.mu.Lock()
+			m.cancelErr = ctx.Err()
+			m.conn.SetDeadline(time.Unix(1, 0))
+			m.mu.Unlock()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// armMessage prepares the connection for reading a message.
+func (m *MessageHandler) armMessage() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancelErr != nil {
+		return m.cancelErr
+	}
+	return m.conn.SetReadDeadline(m.readDeadline)
+}
+
+// armRead prepares the connection for reading raw data.
+func (m *MessageHandler) armRead() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancelErr != nil {
+		return m.cancelErr
+	}
+	if m.stall > 0 {
+		return m.conn.SetReadDeadline(time.Now().Add(m.stall))
+	}
+	return nil
+}
+
+func (m *MessageHandler) armWrite() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancelErr != nil {
+		return m.cancelErr
+	}
+	if m.stall > 0 {
+		return m.conn.SetWriteDeadline(time.Now().Add(m.stall))
+	}
+	return nil
+}
+
+// explain turns timeouts caused by cancellation or the stall timeout into
+// errors saying so.
+func (m *MessageHandler) explain(err error, stalled bool) error {
+	if err == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancelErr != nil {
+		return m.cancelErr
+	}
+	if stalled && m.stall > 0 && errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: no progress for %v", ErrStalled, m.stall)
+	}
+	return err
+}
+
+// readFrom copies r straight to the connection, renewing the stall deadline
+// for every piece.
+func (m *MessageHandler) readFrom(r io.Reader) (int64, error) {
+	m.mu.Lock()
+	stall := m.stall
+	m.mu.Unlock()
+	if stall == 0 {
+		if err := m.armWrite(); err != nil {
+			return 0, err
+		}
+		n, err := io.Copy(m.conn, r)
+		return n, m.explain(err, false)
+	}
+
+	// Keep sendfile working by only ever wrapping the file itself
+	lr, ok := r.(*io.LimitedReader)
+	if !ok {
+		lr = &io.LimitedReader{R: r, N: math.MaxInt64}
+	}
+	total := int64(0)
+	for lr.N > 0 {
+		piece := &io.LimitedReader{R: lr.R, N: readFromPiece}
+		if lr.N < piece.N {
+			piece.N = lr.N
+		}
+		if err := m.armWrite(); err != nil {
+			return total, err
+		}
+		n, err := io.Copy(m.conn, piece)
+		total += n
+		lr.N -= n
+		if err != nil {
+			return total, m.explain(err, true)
+		}
+		if piece.N > 0 {
+			// r ran out
+			break
+		}
+	}
+	return total, nil
+}
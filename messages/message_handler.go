@@ -1,96 +1,410 @@
 package messages
 
 import (
+	"bufio"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"net"
+	"sync"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 )
 
+// Control messages are small; anything claiming to be larger than this is
+// a corrupt or hostile length prefix.
+const MaxMessageSize = 16 * 1024 * 1024
+
+const bufferSize = 64 * 1024
+
+// Buffers for serialized messages are reused, except for the rare large
+// ones which would otherwise be kept around for good.
+const maxPooledBuffer = 1024 * 1024
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 4096)
+		return &buf
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) <= maxPooledBuffer {
+		*buf = (*buf)[:0]
+		bufferPool.Put(buf)
+	}
+}
+
+// A MessageHandler reads and writes through buffers. Raw data written with
+// Write is only guaranteed to be sent once the next message is, so every
+// transfer has to end with a message (usually the checksum) before waiting
+// for the peer.
 type MessageHandler struct {
-	conn net.Conn
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+	prefix [8]byte
+
+	// The server tags its responses with the ID of the request they answer,
+	// and the client remembers the last one it saw along with its error code
+	requestID string
+	errorCode ErrorCode
+	failure   ErrorCode // for the next response sent
+	logger    *log.Logger
+
+	answerPings bool
+
+	mu           sync.Mutex
+	readDeadline time.Time
+	stall        time.Duration
+	cancelErr    error
 }
 
 func NewMessageHandler(conn net.Conn) *MessageHandler {
 	m := &MessageHandler{
-		conn: conn,
+		conn:   conn,
+		reader: bufio.NewReaderSize(conn, bufferSize),
+		writer: bufio.NewWriterSize(conn, bufferSize),
 	}
 
 	return m
 }
 
-func (m *MessageHandler) ReadN(buf []byte) error {
-	bytesRead := uint64(0)
-	for bytesRead < uint64(len(buf)) {
-		n, err := m.conn.Read(buf[bytesRead:])
-		if err != nil {
-			return err
-		}
-		bytesRead += uint64(n)
+// SetRequestID sets the ID that responses sent from now on carry.
+func (m *MessageHandler) SetRequestID(id string) {
+	m.requestID = id
+}
+
+// RequestID returns the ID of the request answered by the last response
+// received, for matching it up with the server's logs.
+func (m *MessageHandler) RequestID() string {
+	return m.requestID
+}
+
+// ErrInvalidChecksum is the failure reported for data that does not match
+// its checksum.
+var ErrInvalidChecksum = errors.New("Invalid checksum")
+
+// Failure returns the message for a failed response about err, and makes the
+// next response sent carry the matching error code.
+func (m *MessageHandler) Failure(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		m.failure = ErrorCode_NOT_FOUND
+	case errors.Is(err, fs.ErrExist):
+		m.failure = ErrorCode_EXISTS
+	case errors.Is(err, ErrInvalidChecksum):
+		m.failure = ErrorCode_CHECKSUM_MISMATCH
+	default:
+		m.failure = ErrorCode_ERROR_UNSPECIFIED
+	}
+	return err.Error()
+}
+
+// ErrorCode returns the error code of the last response received.
+func (m *MessageHandler) ErrorCode() ErrorCode {
+	return m.errorCode
+}
+
+// response builds a Response carrying the current request ID and the error
+// code set by Failure, if the response is one.
+func (m *MessageHandler) response(ok bool, str string) *Response {
+	resp := &Response{Ok: ok, Message: str, RequestId: m.requestID}
+	if !ok {
+		resp.Code = m.failure
 	}
-	return nil
+	m.failure = ErrorCode_ERROR_UNSPECIFIED
+	return resp
+}
+
+// received records the request ID and error code of a response.
+func (m *MessageHandler) received(resp *Response) {
+	m.requestID = resp.GetRequestId()
+	m.errorCode = resp.GetCode()
+}
+
+// SetLogger makes the messages of responses received from now on go to
+// logger, along with their request IDs. Nil, the default, discards them.
+func (m *MessageHandler) SetLogger(logger *log.Logger) {
+	m.logger = logger
+}
+
+// logResponse records the request ID of a response and logs its message.
+func (m *MessageHandler) logResponse(resp *Response) {
+	m.received(resp)
+	switch {
+	case m.logger == nil:
+	case m.requestID == "":
+		m.logger.Println(resp.GetMessage())
+	default:
+		m.logger.Printf("%s (request %s)", resp.GetMessage(), m.requestID)
+	}
+}
+
+func (m *MessageHandler) ReadN(buf []byte) error {
+	_, err := io.ReadFull(m, buf)
+	return err
 }
 
 func (m *MessageHandler) Read(p []byte) (n int, err error) {
-	return m.conn.Read(p)
+	if err := m.armRead(); err != nil {
+		return 0, err
+	}
+	n, err = m.reader.Read(p)
+	return n, m.explain(err, true)
 }
 
 func (m *MessageHandler) Write(p []byte) (n int, err error) {
-	return m.conn.Write(p)
+	if err := m.armWrite(); err != nil {
+		return 0, err
+	}
+	n, err = m.writer.Write(p)
+	return n, m.explain(err, true)
 }
 
-func (m *MessageHandler) WriteN(buf []byte) error {
-	bytesWritten := uint64(0)
-	for bytesWritten < uint64(len(buf)) {
-		n, err := m.conn.Write(buf[bytesWritten:])
-		if err != nil {
-			return err
-		}
-		bytesWritten += uint64(n)
+// ReadFrom lets io.Copy hand a file straight to the connection, which for TCP
+// connections means sendfile or splice instead of copying through userspace.
+func (m *MessageHandler) ReadFrom(r io.Reader) (int64, error) {
+	if err := m.armWrite(); err != nil {
+		return 0, err
+	}
+	if err := m.writer.Flush(); err != nil {
+		return 0, m.explain(err, true)
 	}
-	return nil
+	return m.readFrom(r)
+}
+
+func (m *MessageHandler) WriteN(buf []byte) error {
+	_, err := m.Write(buf)
+	return err
 }
 
+// Send writes wrapper, along with any raw data still buffered, in a single
+// write if it fits in the buffer.
 func (m *MessageHandler) Send(wrapper *Wrapper) error {
-	serialized, err := proto.Marshal(wrapper)
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	serialized, err := proto.MarshalOptions{}.MarshalAppend(append(*buf, make([]byte, 8)...), wrapper)
 	if err != nil {
 		return err
 	}
+	*buf = serialized
+	binary.LittleEndian.PutUint64(serialized, uint64(len(serialized)-8))
 
-	prefix := make([]byte, 8)
-	binary.LittleEndian.PutUint64(prefix, uint64(len(serialized)))
-	m.WriteN(prefix)
-	m.WriteN(serialized)
+	if err := m.armWrite(); err != nil {
+		return err
+	}
+	if _, err := m.writer.Write(serialized); err != nil {
+		return m.explain(err, true)
+	}
+	return m.explain(m.writer.Flush(), true)
+}
 
-	return nil
+// AnswerPings makes Receive answer pings from the peer itself rather than
+// return them, for clients that the server pings while they are quiet.
+func (m *MessageHandler) AnswerPings() {
+	m.answerPings = true
 }
 
 func (m *MessageHandler) Receive() (*Wrapper, error) {
-	prefix := make([]byte, 8)
-	m.ReadN(prefix)
+	for {
+		wrapper, err := m.receive()
+		if err != nil || !m.answerPings || wrapper.GetPing() == nil {
+			return wrapper, err
+		}
+		if err := m.SendPong(wrapper.GetPing().Seq); err != nil {
+			return nil, err
+		}
+	}
+}
 
-	payloadSize := binary.LittleEndian.Uint64(prefix)
-	payload := make([]byte, payloadSize)
-	m.ReadN(payload)
+func (m *MessageHandler) receive() (*Wrapper, error) {
+	m.errorCode = ErrorCode_ERROR_UNSPECIFIED
+	if err := m.armMessage(); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(m.reader, m.prefix[:]); err != nil {
+		return nil, m.explain(err, false)
+	}
 
+	payloadSize := binary.LittleEndian.Uint64(m.prefix[:])
+	if payloadSize > MaxMessageSize {
+		return nil, fmt.Errorf("message too large: %d bytes", payloadSize)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if uint64(cap(*buf)) < payloadSize {
+		*buf = make([]byte, payloadSize)
+	}
+	payload := (*buf)[:payloadSize]
+	if _, err := io.ReadFull(m.reader, payload); err != nil {
+		return nil, m.explain(err, false)
+	}
+
+	// Unmarshal copies what it needs, so the buffer can be reused
 	wrapper := &Wrapper{}
-	err := proto.Unmarshal(payload, wrapper)
-	return wrapper, err
+	if err := proto.Unmarshal(payload, wrapper); err != nil {
+		return nil, err
+	}
+	if goodbye := wrapper.GetGoodbye(); goodbye != nil {
+		return nil, &GoodbyeError{Reason: goodbye.Reason}
+	}
+	return wrapper, nil
+}
+
+// A GoodbyeError is returned by Receive when the peer ends the session.
+type GoodbyeError struct {
+	Reason string
+}
+
+func (e *GoodbyeError) Error() string {
+	if e.Reason == "" {
+		return "session closed by peer"
+	}
+	return "session closed by peer: " + e.Reason
 }
 
 func (m *MessageHandler) Close() {
 	m.conn.Close()
 }
 
-func (m *MessageHandler) SendStorageRequest(fileName string, size uint64) error {
-	msg := StorageRequest{FileName: fileName, Size: size}
+func (m *MessageHandler) SendGoodbye(reason string) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_Goodbye{Goodbye: &Goodbye{Reason: reason}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendPing(seq uint64, interval time.Duration, misses uint32) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_Ping{Ping: &Ping{Seq: seq, Interval: int64(interval), Misses: misses}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendPong(seq uint64) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_Pong{Pong: &Pong{Seq: seq}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendSetRateLimits(global int64, perUser int64, perConnection int64) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_SetRateLimits{SetRateLimits: &SetRateLimits{Global: global, PerUser: perUser, PerConnection: perConnection}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendStorageRequest(fileName string, size uint64, metadata *FileMetadata, overwrite bool, encrypted bool) error {
+	msg := StorageRequest{FileName: fileName, Size: size, Metadata: metadata, Overwrite: overwrite, Encrypted: encrypted}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_StorageReq{StorageReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+// SendParallelStorageRequest starts an upload whose data is sent in parts
+// over other connections.
+func (m *MessageHandler) SendParallelStorageRequest(fileName string, size uint64, parts uint32, metadata *FileMetadata, overwrite bool) error {
+	msg := StorageRequest{FileName: fileName, Size: size, Parts: parts, Metadata: metadata, Overwrite: overwrite}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_StorageReq{StorageReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+// SendSparseStorageRequest starts an upload of which only the data extents
+// are sent.
+func (m *MessageHandler) SendSparseStorageRequest(fileName string, size uint64, extents []*Extent, metadata *FileMetadata, overwrite bool) error {
+	msg := StorageRequest{FileName: fileName, Size: size, Sparse: true, Extents: extents, Metadata: metadata, Overwrite: overwrite}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_StorageReq{StorageReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+// SendChunkedStorageRequest starts an upload of unknown length, whose data
+// follows in chunks.
+func (m *MessageHandler) SendChunkedStorageRequest(fileName string, overwrite bool) error {
+	msg := StorageRequest{FileName: fileName, Chunked: true, Overwrite: overwrite}
 	wrapper := &Wrapper{
 		Msg: &Wrapper_StorageReq{StorageReq: &msg},
 	}
 	return m.Send(wrapper)
 }
 
+func (m *MessageHandler) SendRangeStorageRequest(transferID string, offset uint64, length uint64) error {
+	msg := RangeStorageRequest{TransferId: transferID, Offset: offset, Length: length}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_RangeStorageReq{RangeStorageReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendRangeRetrievalRequest(fileName string, offset uint64, length uint64) error {
+	msg := RangeRetrievalRequest{FileName: fileName, Offset: offset, Length: length}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_RangeRetrievalReq{RangeRetrievalReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendStatRequest(fileName string, checksum bool) error {
+	msg := StatRequest{FileName: fileName, Checksum: checksum}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_StatReq{StatReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendListRequest(path string, recursive bool) error {
+	msg := ListRequest{Path: path, Recursive: recursive}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_ListReq{ListReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendDeleteRequest(fileName string) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_DeleteReq{DeleteReq: &DeleteRequest{FileName: fileName}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendRenameRequest(from string, to string) error {
+	wrapper := &Wrapper{
+		Msg: &Wrapper_RenameReq{RenameReq: &RenameRequest{From: from, To: to}},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendTarStorageRequest(path string, size uint64) error {
+	msg := TarStorageRequest{Path: path, Size: size}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_TarStorageReq{TarStorageReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendTarRetrievalRequest(path string) error {
+	msg := TarRetrievalRequest{Path: path}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_TarRetrievalReq{TarRetrievalReq: &msg},
+	}
+	return m.Send(wrapper)
+}
+
 func (m *MessageHandler) SendRetrievalRequest(fileName string) error {
 	msg := RetrievalRequest{FileName: fileName}
 	wrapper := &Wrapper{
@@ -108,17 +422,56 @@ func (m *MessageHandler) SendChecksumVerification(checksum []byte) error {
 }
 
 func (m *MessageHandler) SendResponse(ok bool, str string) error {
-	msg := Response{Ok: ok, Message: str}
 	wrapper := &Wrapper{
-		Msg: &Wrapper_Response{Response: &msg},
+		Msg: &Wrapper_Response{Response: m.response(ok, str)},
+	}
+
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendTransferResponse(ok bool, str string, transferID string) error {
+	msg := m.response(ok, str)
+	msg.TransferId = transferID
+	wrapper := &Wrapper{
+		Msg: &Wrapper_Response{Response: msg},
 	}
 
 	return m.Send(wrapper)
 }
 
-func (m *MessageHandler) SendRetrievalResponse(ok bool, str string, size uint64) error {
-	resp := Response{Ok: ok, Message: str}
-	msg := RetrievalResponse{Resp: &resp, Size: size}
+func (m *MessageHandler) SendStatResponse(ok bool, str string, size uint64, checksum []byte, metadata *FileMetadata, encrypted bool) error {
+	resp := m.response(ok, str)
+	msg := StatResponse{Resp: resp, Size: size, Checksum: checksum, Metadata: metadata, Encrypted: encrypted}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_StatResp{StatResp: &msg},
+	}
+
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendListResponse(ok bool, str string, entries []*FileEntry) error {
+	resp := m.response(ok, str)
+	msg := ListResponse{Resp: resp, Entries: entries}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_ListResp{ListResp: &msg},
+	}
+
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendTarResponse(ok bool, str string, size uint64, entries uint32, errors []*TarEntryError) error {
+	resp := m.response(ok, str)
+	msg := TarResponse{Resp: resp, Size: size, Entries: entries, Errors: errors}
+	wrapper := &Wrapper{
+		Msg: &Wrapper_TarResp{TarResp: &msg},
+	}
+
+	return m.Send(wrapper)
+}
+
+func (m *MessageHandler) SendRetrievalResponse(ok bool, str string, size uint64, metadata *FileMetadata, encrypted bool) error {
+	resp := m.response(ok, str)
+	msg := RetrievalResponse{Resp: resp, Size: size, Metadata: metadata, Encrypted: encrypted}
 	wrapper := &Wrapper{
 		Msg: &Wrapper_RetrievalResp{RetrievalResp: &msg},
 	}
@@ -129,20 +482,77 @@ func (m *MessageHandler) SendRetrievalResponse(ok bool, str string, size uint64)
 func (m *MessageHandler) ReceiveResponse() (bool, string) {
 	resp, err := m.Receive()
 	if err != nil {
-		return false, ""
+		return false, err.Error()
 	}
 
-	log.Println(resp.GetResponse().Message)
-	return resp.GetResponse().Ok, resp.GetResponse().Message
+	m.logResponse(resp.GetResponse())
+	return resp.GetResponse().GetOk(), resp.GetResponse().GetMessage()
 }
 
-func (m *MessageHandler) ReceiveRetrievalResponse() (bool, string, uint64) {
+func (m *MessageHandler) ReceiveRetrievalResponse() (bool, string, uint64, *FileMetadata, bool) {
 	resp, err := m.Receive()
 	if err != nil {
-		return false, "", 0
+		return false, err.Error(), 0, nil, false
 	}
 
-	rr := resp.GetRetrievalResp().GetResp()
-	log.Println(rr.Message)
-	return rr.Ok, rr.Message, resp.GetRetrievalResp().Size
+	rr := resp.GetRetrievalResp()
+	m.logResponse(rr.GetResp())
+	return rr.GetResp().GetOk(), rr.GetResp().GetMessage(), rr.GetSize(), rr.GetMetadata(), rr.GetEncrypted()
+}
+
+func (m *MessageHandler) ReceiveTransferResponse() (bool, string, string) {
+	resp, err := m.Receive()
+	if err != nil {
+		return false, err.Error(), ""
+	}
+
+	r := resp.GetResponse()
+	m.logResponse(r)
+	return r.GetOk(), r.GetMessage(), r.GetTransferId()
+}
+
+func (m *MessageHandler) ReceiveStatResponse() (bool, string, uint64, []byte, *FileMetadata, bool) {
+	resp, err := m.Receive()
+	if err != nil {
+		return false, err.Error(), 0, nil, nil, false
+	}
+
+	sr := resp.GetStatResp()
+	m.received(sr.GetResp())
+	return sr.GetResp().GetOk(), sr.GetResp().GetMessage(), sr.GetSize(), sr.GetChecksum(), sr.GetMetadata(), sr.GetEncrypted()
+}
+
+func (m *MessageHandler) ReceiveListResponse() (bool, string, []*FileEntry) {
+	resp, err := m.Receive()
+	if err != nil {
+		return false, err.Error(), nil
+	}
+
+	lr := resp.GetListResp()
+	m.received(lr.GetResp())
+	return lr.GetResp().GetOk(), lr.GetResp().GetMessage(), lr.GetEntries()
+}
+
+func (m *MessageHandler) ReceiveTarResponse() (bool, string, uint64, uint32, []*TarEntryError) {
+	resp, err := m.Receive()
+	if err != nil {
+		return false, err.Error(), 0, 0, nil
+	}
+
+	tr := resp.GetTarResp()
+	m.logResponse(tr.GetResp())
+	return tr.GetResp().GetOk(), tr.GetResp().GetMessage(), tr.GetSize(), tr.GetEntries(), tr.GetErrors()
+}
+
+// ReceiveChecksum waits for the checksum that follows a file transfer.
+func (m *MessageHandler) ReceiveChecksum() ([]byte, error) {
+	wrapper, err := m.Receive()
+	if err != nil {
+		return nil, err
+	}
+	check := wrapper.GetChecksum()
+	if check == nil {
+		return nil, fmt.Errorf("expected checksum, got %T", wrapper.Msg)
+	}
+	return check.Checksum, nil
 }
@@ -0,0 +1,461 @@
+package messages
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// Each stream may have this many unacknowledged bytes in flight.
+	StreamWindow = 256 * 1024
+
+	// Writes are split into frames of at most this size, so that a large
+	// transfer only holds the connection for one frame at a time.
+	MaxFrameData = 32 * 1024
+)
+
+var ErrMuxClosed = errors.New("multiplexed connection closed")
+
+// ErrWindowExceeded means the peer sent more on a stream than its flow
+// control window allowed.
+var ErrWindowExceeded = errors.New("stream flow control window exceeded")
+
+// ErrUnresponsive means the peer stopped answering keepalive pings.
+var ErrUnresponsive = errors.New("peer stopped answering pings")
+
+// A Mux carries any number of independent byte streams over one connection.
+// Every frame is tagged with its stream ID, streams with data to send take
+// turns one frame at a time, and each stream has its own flow control window
+// so a slow reader on one stream cannot stall the others.
+//
+// Only the client opens streams; the server accepts them.
+type Mux struct {
+	msgHandler *MessageHandler
+	isServer   bool
+	accept     chan *Stream
+	done       chan struct{}
+
+	mu       sync.Mutex
+	sendCond *sync.Cond
+	streams  map[uint32]*Stream
+	lastID   uint32
+	ready    []*Stream   // Streams with frames waiting, in turn order
+	control  []*MuxFrame // Window updates and pings jump the queue
+	sending  bool
+	err      error
+	open     int           // Streams not yet closed on this side
+	idle     time.Duration // How long the connection may go without open streams
+
+	pingSeq    uint64
+	unanswered int // Pings sent since the last pong
+}
+
+func NewMux(msgHandler *MessageHandler, isServer bool) *Mux {
+	x := &Mux{
+		msgHandler: msgHandler,
+		isServer:   isServer,
+		accept:     make(chan *Stream, 16),
+		done:       make(chan struct{}),
+		streams:    make(map[uint32]*Stream),
+	}
+	x.sendCond = sync.NewCond(&x.mu)
+
+	go x.readLoop()
+	go x.writeLoop()
+	return x
+}
+
+func (x *Mux) newStream(id uint32) *Stream {
+	s := &Stream{id: id, mux: x, sendWindow: StreamWindow}
+	s.recvCond = sync.NewCond(&x.mu)
+	x.streams[id] = s
+	x.open++
+	return s
+}
+
+// SetIdleTimeout closes the connection once it has had no open streams for
+// d. Zero, the default, disables it.
+func (x *Mux) SetIdleTimeout(d time.Duration) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.idle = d
+	x.armIdle()
+}
+
+// armIdle starts the idle timeout when the last stream has closed, and stops
+// it while there are streams. Callers must hold mu.
+func (x *Mux) armIdle() {
+	if x.idle == 0 {
+		return
+	}
+	if x.open == 0 {
+		x.msgHandler.SetReadDeadline(time.Now().Add(x.idle))
+	} else {
+		x.msgHandler.SetReadDeadline(time.Time{})
+	}
+}
+
+// Keepalive pings the peer on the control stream every interval, and shuts
+// down the connection once misses pings in a row have gone unanswered.
+func (x *Mux) Keepalive(interval time.Duration, misses int) {
+	if interval <= 0 || misses <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-x.done:
+				return
+			case <-ticker.C:
+			}
+			x.mu.Lock()
+			if x.unanswered >= misses {
+				x.mu.Unlock()
+				x.fail(ErrUnresponsive)
+				return
+			}
+			x.pingSeq++
+			x.unanswered++
+			x.control = append(x.control, &MuxFrame{Ping: &Ping{Seq: x.pingSeq}})
+			x.sendCond.Broadcast()
+			x.mu.Unlock()
+		}
+	}()
+}
+
+// Open starts a new stream. An empty frame announces it right away, so the
+// peer sees streams in the order they were opened.
+func (x *Mux) Open() (*Stream, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.err != nil {
+		return nil, x.err
+	}
+	x.lastID++
+	s := x.newStream(x.lastID)
+	x.queue(s, &MuxFrame{StreamId: s.id})
+	return s, nil
+}
+
+// Accept waits for the peer to open a stream.
+func (x *Mux) Accept() (*Stream, error) {
+	s, ok := <-x.accept
+	if !ok {
+		return nil, x.err
+	}
+	return s, nil
+}
+
+// Close sends whatever is still queued and then closes the connection.
+func (x *Mux) Close() error {
+	x.mu.Lock()
+	for x.err == nil && (x.sending || len(x.control) > 0 || len(x.ready) > 0) {
+		x.sendCond.Wait()
+	}
+	x.mu.Unlock()
+
+	x.fail(ErrMuxClosed)
+	return nil
+}
+
+// fail shuts down the mux and every stream on it. Callers must not hold mu.
+func (x *Mux) fail(err error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.err != nil {
+		return
+	}
+	x.err = err
+	x.msgHandler.Close()
+	close(x.accept)
+	close(x.done)
+	x.sendCond.Broadcast()
+	for _, s := range x.streams {
+		s.recvCond.Broadcast()
+	}
+}
+
+func (x *Mux) readLoop() {
+	for {
+		wrapper, err := x.msgHandler.Receive()
+		if err != nil {
+			if err == io.EOF {
+				err = ErrMuxClosed
+			}
+			x.fail(err)
+			return
+		}
+		frame := wrapper.GetFrame()
+		if frame == nil {
+			x.fail(errors.New("expected a multiplexed frame"))
+			return
+		}
+
+		x.mu.Lock()
+		if frame.StreamId == 0 {
+			x.handleControl(frame)
+			x.mu.Unlock()
+			continue
+		}
+		s := x.streams[frame.StreamId]
+		if s == nil && x.isServer && x.err == nil && frame.StreamId > x.lastID {
+			x.lastID = frame.StreamId
+			s = x.newStream(frame.StreamId)
+			x.armIdle()
+			x.accept <- s
+		}
+		if s != nil {
+			err = s.receive(frame)
+		}
+		x.mu.Unlock()
+		if err != nil {
+			x.fail(err)
+			return
+		}
+	}
+}
+
+func (x *Mux) writeLoop() {
+	for {
+		x.mu.Lock()
+		for x.err == nil && len(x.control) == 0 && len(x.ready) == 0 {
+			x.sendCond.Wait()
+		}
+		if x.err != nil {
+			x.mu.Unlock()
+			return
+		}
+
+		var frame *MuxFrame
+		if len(x.control) > 0 {
+			frame = x.control[0]
+			x.control = x.control[1:]
+		} else {
+			s := x.ready[0]
+			x.ready = x.ready[1:]
+			frame = s.outgoing[0]
+			s.outgoing = s.outgoing[1:]
+			if len(s.outgoing) > 0 {
+				x.ready = append(x.ready, s) // Back of the line
+			}
+			if frame.Fin {
+				s.finSent = true
+				x.forget(s)
+			}
+		}
+		x.sending = true
+		x.mu.Unlock()
+
+		if err := x.msgHandler.Send(&Wrapper{Msg: &Wrapper_Frame{Frame: frame}}); err != nil {
+			x.fail(err)
+			return
+		}
+
+		x.mu.Lock()
+		x.sending = false
+		x.sendCond.Broadcast()
+		x.mu.Unlock()
+	}
+}
+
+// handleControl handles a frame on the control stream: it answers pings and
+// takes note of pongs. Callers must hold mu.
+func (x *Mux) handleControl(frame *MuxFrame) {
+	if ping := frame.GetPing(); ping != nil {
+		x.control = append(x.control, &MuxFrame{Pong: &Pong{Seq: ping.Seq}})
+		x.sendCond.Broadcast()
+	}
+	if frame.GetPong() != nil {
+		x.unanswered = 0
+	}
+}
+
+// forget drops a stream once neither side will send on it again.
+func (x *Mux) forget(s *Stream) {
+	if s.finSent && s.finReceived {
+		delete(x.streams, s.id)
+	}
+}
+
+// queue adds a frame to the end of a stream's outgoing frames.
+func (x *Mux) queue(s *Stream, frame *MuxFrame) {
+	if len(s.outgoing) == 0 {
+		x.ready = append(x.ready, s)
+	}
+	s.outgoing = append(s.outgoing, frame)
+	x.sendCond.Broadcast()
+}
+
+// A Stream is one conversation on a Mux. It implements net.Conn, so a
+// MessageHandler can be layered on top of it just like a TCP connection.
+// All of its state is guarded by the Mux's lock.
+type Stream struct {
+	id  uint32
+	mux *Mux
+
+	recvCond     *sync.Cond
+	received     bytes.Buffer
+	unacked      uint32
+	finReceived  bool
+	readDeadline time.Time
+	readTimer    *time.Timer
+
+	sendWindow uint32
+	outgoing   []*MuxFrame
+	closed     bool
+	finSent    bool
+}
+
+// receive takes in a frame from the peer, which must stay within the window
+// the stream has handed out: whatever is buffered or read but not yet
+// acknowledged.
+func (s *Stream) receive(frame *MuxFrame) error {
+	if len(frame.Data) > 0 && !s.closed {
+		if s.received.Len()+int(s.unacked)+len(frame.Data) > StreamWindow {
+			return ErrWindowExceeded
+		}
+		s.received.Write(frame.Data)
+	}
+	if frame.WindowUpdate > 0 {
+		s.sendWindow += frame.WindowUpdate
+		s.mux.sendCond.Broadcast()
+	}
+	if frame.Fin {
+		s.finReceived = true
+		s.mux.forget(s)
+	}
+	s.recvCond.Broadcast()
+	return nil
+}
+
+func (s *Stream) Read(p []byte) (int, error) {
+	x := s.mux
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for s.received.Len() == 0 {
+		switch {
+		case s.closed:
+			return 0, net.ErrClosed
+		case s.finReceived:
+			return 0, io.EOF
+		case x.err != nil:
+			return 0, x.err
+		case !s.readDeadline.IsZero() && !time.Now().Before(s.readDeadline):
+			return 0, os.ErrDeadlineExceeded
+		}
+		s.recvCond.Wait()
+	}
+
+	n, _ := s.received.Read(p)
+
+	// Hand the window back once half of it has been consumed
+	s.unacked += uint32(n)
+	if s.unacked >= StreamWindow/2 {
+		x.control = append(x.control, &MuxFrame{StreamId: s.id, WindowUpdate: s.unacked})
+		s.unacked = 0
+		x.sendCond.Broadcast()
+	}
+	return n, nil
+}
+
+func (s *Stream) Write(p []byte) (int, error) {
+	x := s.mux
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	written := 0
+	for written < len(p) {
+		for s.sendWindow == 0 && !s.closed && x.err == nil {
+			x.sendCond.Wait()
+		}
+		if s.closed {
+			return written, net.ErrClosed
+		}
+		if x.err != nil {
+			return written, x.err
+		}
+
+		n := len(p) - written
+		if n > MaxFrameData {
+			n = MaxFrameData
+		}
+		if uint32(n) > s.sendWindow {
+			n = int(s.sendWindow)
+		}
+		data := make([]byte, n)
+		copy(data, p[written:])
+		x.queue(s, &MuxFrame{StreamId: s.id, Data: data})
+		s.sendWindow -= uint32(n)
+		written += n
+	}
+	return written, nil
+}
+
+// Close ends the stream in both directions. Anything already written is
+// still delivered.
+func (s *Stream) Close() error {
+	x := s.mux
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	x.open--
+	x.armIdle()
+	if s.readTimer != nil {
+		s.readTimer.Stop()
+	}
+	s.recvCond.Broadcast()
+	x.sendCond.Broadcast()
+	if x.err == nil {
+		x.queue(s, &MuxFrame{StreamId: s.id, Fin: true})
+	}
+	return nil
+}
+
+func (s *Stream) LocalAddr() net.Addr {
+	return s.mux.msgHandler.conn.LocalAddr()
+}
+
+func (s *Stream) RemoteAddr() net.Addr {
+	return s.mux.msgHandler.conn.RemoteAddr()
+}
+
+func (s *Stream) SetDeadline(t time.Time) error {
+	return s.SetReadDeadline(t)
+}
+
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	x := s.mux
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	s.readDeadline = t
+	if s.readTimer != nil {
+		s.readTimer.Stop()
+		s.readTimer = nil
+	}
+	if !t.IsZero() {
+		s.readTimer = time.AfterFunc(time.Until(t), func() {
+			x.mu.Lock()
+			s.recvCond.Broadcast()
+			x.mu.Unlock()
+		})
+	}
+	return nil
+}
+
+// Writes only wait for flow control, so write deadlines are not supported.
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	return nil
+}
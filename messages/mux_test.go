@@ -0,0 +1,121 @@
+package messages
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMuxFlowControl(t *testing.T) {
+	client, server := loopback(t)
+	clientMux, serverMux := NewMux(client, false), NewMux(server, true)
+
+	// Several windows' worth only gets through if the reader hands the
+	// window back as it goes
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4*StreamWindow/16)
+	stream, err := clientMux.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		stream.Write(data)
+		stream.Close()
+	}()
+
+	peer, err := serverMux.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(peer)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("read %d bytes (%v), want %d", len(got), err, len(data))
+	}
+	if err := peer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := peer.Read(make([]byte, 1)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("read from a closed stream: %v, want net.ErrClosed", err)
+	}
+	if _, err := peer.Write(data[:1]); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("write to a closed stream: %v, want net.ErrClosed", err)
+	}
+}
+
+func TestMuxWindowExceeded(t *testing.T) {
+	client, server := loopback(t)
+	mux := NewMux(server, true)
+
+	// The peer keeps sending on a stream nobody reads
+	for sent := 0; sent <= StreamWindow; sent += MaxFrameData {
+		frame := &MuxFrame{StreamId: 1, Data: make([]byte, MaxFrameData)}
+		if err := client.Send(&Wrapper{Msg: &Wrapper_Frame{Frame: frame}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := mux.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := mux.Accept()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrWindowExceeded) {
+			t.Fatalf("mux failed with %v, want ErrWindowExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("mux still open after the window was exceeded")
+	}
+	if _, err := client.Receive(); err == nil {
+		t.Fatal("connection still open after the window was exceeded")
+	}
+}
+
+func TestMuxKeepalive(t *testing.T) {
+	client, server := loopback(t)
+	clientMux, serverMux := NewMux(client, false), NewMux(server, true)
+	clientMux.Keepalive(10*time.Millisecond, 2)
+	serverMux.Keepalive(10*time.Millisecond, 2)
+
+	// Both ends answer pings, so an idle connection stays up
+	time.Sleep(100 * time.Millisecond)
+	if _, err := clientMux.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := serverMux.Accept(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMuxKeepaliveSilentPeer(t *testing.T) {
+	client, server := loopback(t)
+	mux := NewMux(client, false)
+	mux.Keepalive(10*time.Millisecond, 2)
+
+	// The peer reads the pings but never answers them
+	go func() {
+		for {
+			if _, err := server.Receive(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := mux.Open(); err != nil {
+			if !errors.Is(err, ErrUnresponsive) {
+				t.Fatalf("mux failed with %v, want ErrUnresponsive", err)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("mux still open after the peer stopped answering")
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"file-transfer/messages"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	maxConnections = flag.Int("max-connections", 0, "refuse connections beyond this many (0 means no limit)")
+	maxPerIP       = flag.Int("max-connections-per-ip", 0, "refuse connections from a client address beyond this many (0 means no limit)")
+	maxTransfers   = flag.Int("max-transfers", 0, "run at most this many transfers at once and queue the rest (0 means no limit)")
+	queueTimeout   = flag.Duration("queue-timeout", 10*time.Second, "refuse queued transfers that have not started after this long")
+	retryAfter     = flag.Duration("retry-after", 10*time.Second, "how long refused clients are told to wait before trying again")
+	maxStreams     = flag.Int("max-streams", 64, "refuse streams beyond this many on one multiplexed connection")
+)
+
+// busyMessage tells a refused client when to come back.
+func busyMessage() string {
+	return fmt.Sprintf("server busy, retry after %d seconds", int(retryAfter.Seconds()))
+}
+
+var connections = struct {
+	sync.Mutex
+	total int
+	perIP map[string]int
+}{perIP: make(map[string]int)}
+
+// admit counts a new connection from addr, or reports false if that would
+// exceed a connection limit. Admitted connections are released when they end.
+func admit(addr net.Addr) (release func(), ok bool) {
+	ip := clientIP(addr)
+	connections.Lock()
+	defer connections.Unlock()
+	if *maxConnections > 0 && connections.total >= *maxConnections {
+		return nil, false
+	}
+	if *maxPerIP > 0 && connections.perIP[ip] >= *maxPerIP {
+		return nil, false
+	}
+	connections.total++
+	connections.perIP[ip]++
+
+	return func() {
+		connections.Lock()
+		defer connections.Unlock()
+		connections.total--
+		if connections.perIP[ip]--; connections.perIP[ip] == 0 {
+			delete(connections.perIP, ip)
+		}
+	}, true
+}
+
+// clientIP identifies the client a connection comes from.
+func clientIP(addr net.Addr) string {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP.String()
+	}
+	return addr.String()
+}
+
+// At most this many refused connections are kept open at once to tell the
+// client why, for up to refuseLinger each. Beyond that they are simply
+// closed, so a flood of connections cannot pile up goroutines.
+const (
+	maxRefusing  = 64
+	refuseLinger = time.Second
+)
+
+var refusing = make(chan struct{}, maxRefusing)
+
+// refuse turns a connection or stream away with msg. The client has likely
+// sent a request already, so it is read and discarded until the client hangs
+// up; closing a socket with unread data would reset it before the client
+// sees why.
+func refuse(conn net.Conn, msg string) {
+	select {
+	case refusing <- struct{}{}:
+	default:
+		conn.Close()
+		return
+	}
+	go func() {
+		defer func() { <-refusing }()
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(refuseLinger))
+		msgHandler := messages.NewMessageHandler(conn)
+		if err := msgHandler.SendGoodbye(msg); err != nil {
+			return
+		}
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+		io.Copy(io.Discard, conn)
+	}()
+}
+
+// Transfers wait for a slot before they start, so a burst of requests queues
+// up instead of competing for the disk.
+var transferSlots chan struct{}
+
+func initTransferSlots() {
+	if *maxTransfers > 0 {
+		transferSlots = make(chan struct{}, *maxTransfers)
+	}
+}
+
+// acquireTransfer waits up to queueTimeout for a transfer slot, which the
+// returned function gives back. Handlers call it before moving file data and
+// pass the error on to the client.
+func acquireTransfer(logger *slog.Logger) (release func(), err error) {
+	if transferSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case transferSlots <- struct{}{}:
+	default:
+		timer := time.NewTimer(*queueTimeout)
+		defer timer.Stop()
+		select {
+		case transferSlots <- struct{}{}:
+		case <-timer.C:
+			logger.Warn("Too many transfers, refusing request")
+			return nil, errors.New(busyMessage())
+		}
+	}
+	return func() { <-transferSlots }, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var auditLogFile = flag.String("audit-log", "", "append a hash-chained record of every transfer to this file (keep it outside the download directory)")
+
+// An auditRecord is one line of the audit log. Each record carries the hash
+// of the one before it, so records cannot be changed, removed or reordered
+// without breaking the chain. The hash covers the JSON encoding of the
+// record without its own hash.
+type auditRecord struct {
+	Seq       uint64 `json:"seq"`
+	Time      string `json:"time"`
+	Client    string `json:"client"`
+	Identity  string `json:"identity"` // empty until clients authenticate
+	Request   string `json:"request"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	To        string `json:"to,omitempty"` // the new name, for renames
+	Size      int64  `json:"size"`
+	Checksum  string `json:"checksum,omitempty"`
+	Outcome   string `json:"outcome"`
+	Prev      string `json:"prev"`
+	Hash      string `json:"hash,omitempty"`
+}
+
+func (r auditRecord) digest() (string, error) {
+	r.Hash = ""
+	line, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// The first record chains to this hash.
+var auditGenesis = hex.EncodeToString(make([]byte, sha256.Size))
+
+var audit = struct {
+	sync.Mutex
+	file *os.File
+	seq  uint64
+	last string
+}{last: auditGenesis}
+
+// openAuditLog opens the audit log for appending, continuing the chain of the
+// records already in it.
+func openAuditLog(name string) error {
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	var last auditRecord
+	scanner := newAuditScanner(file)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil || last.Hash == "" {
+			file.Close()
+			return fmt.Errorf("%s is damaged, check it with: %s audit verify %s", name, os.Args[0], name)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return err
+	}
+	if last.Hash != "" {
+		audit.seq, audit.last = last.Seq, last.Hash
+	}
+	audit.file = file
+	return nil
+}
+
+// An auditEntry collects what a handler did for the audit log. Handlers start
+// one and defer write, filling in the rest once they know how it went.
+type auditEntry struct {
+	s         *session
+	operation string
+	path      string
+	to        string
+	size      int64
+	checksum  []byte
+	outcome   string
+}
+
+func (s *session) audit(operation, path string) *auditEntry {
+	return &auditEntry{s: s, operation: operation, path: path, outcome: "failed"}
+}
+
+func (a *auditEntry) write() {
+	if audit.file == nil {
+		return
+	}
+	audit.Lock()
+	defer audit.Unlock()
+	r := auditRecord{
+		Seq:       audit.seq + 1,
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Client:    a.s.addr.String(),
+		Request:   a.s.msgHandler.RequestID(),
+		Operation: a.operation,
+		Path:      a.path,
+		To:        a.to,
+		Size:      a.size,
+		Outcome:   a.outcome,
+		Prev:      audit.last,
+	}
+	if a.checksum != nil {
+		r.Checksum = hex.EncodeToString(a.checksum)
+	}
+	var err error
+	if r.Hash, err = r.digest(); err == nil {
+		var line []byte
+		if line, err = json.Marshal(r); err == nil {
+			_, err = audit.file.Write(append(line, '\n'))
+		}
+	}
+	if err == nil {
+		err = audit.file.Sync()
+	}
+	if err != nil {
+		a.s.log.Error("Unable to write audit log", "error", err)
+		return
+	}
+	audit.seq, audit.last = r.Seq, r.Hash
+}
+
+func newAuditScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	return scanner
+}
+
+// verifyAuditLog checks the chain of the records in r. It returns the
+// number of records and the hash of the last one, which can be kept
+// elsewhere to notice records cut off the end later.
+func verifyAuditLog(r io.Reader) (records uint64, last string, err error) {
+	last = auditGenesis
+	scanner := newAuditScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		var record auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, last, fmt.Errorf("line %d: %w", line, err)
+		}
+		if record.Seq != records+1 {
+			return records, last, fmt.Errorf("line %d: record %d follows record %d", line, record.Seq, records)
+		}
+		if record.Prev != last {
+			return records, last, fmt.Errorf("line %d: record does not follow the one before it", line)
+		}
+		digest, err := record.digest()
+		if err != nil {
+			return records, last, err
+		}
+		if record.Hash != digest {
+			return records, last, fmt.Errorf("line %d: record has been altered", line)
+		}
+		records, last = record.Seq, record.Hash
+	}
+	return records, last, scanner.Err()
+}
+
+// auditCommand runs "audit verify file", returning the exit status.
+func auditCommand(args []string) int {
+	if len(args) != 2 || args[0] != "verify" {
+		fmt.Printf("Usage: %s audit verify audit-log\n", os.Args[0])
+		return 2
+	}
+	file, err := os.Open(args[1])
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	defer file.Close()
+
+	records, last, err := verifyAuditLog(file)
+	if err != nil {
+		fmt.Printf("Audit log is NOT intact after %d records: %v\n", records, err)
+		return 1
+	}
+	fmt.Printf("Audit log is intact: %d records, last hash %s\n", records, last)
+	return 0
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// auditChain returns an intact log of n records about path and the hash of
+// the last.
+func auditChain(t *testing.T, n int, path string) ([]string, string) {
+	t.Helper()
+	var lines []string
+	last := auditGenesis
+	for seq := 1; seq <= n; seq++ {
+		r := auditRecord{Seq: uint64(seq), Operation: "put", Path: path, Outcome: "ok", Prev: last}
+		var err error
+		if r.Hash, err = r.digest(); err != nil {
+			t.Fatal(err)
+		}
+		line, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(line))
+		last = r.Hash
+	}
+	return lines, last
+}
+
+func verifyLines(lines []string) (uint64, string, error) {
+	return verifyAuditLog(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	lines, last := auditChain(t, 4, "file")
+	if records, got, err := verifyLines(lines); err != nil || records != 4 || got != last {
+		t.Fatalf("intact log: %d records, last %s, %v", records, got, err)
+	}
+
+	edited := append([]string(nil), lines...)
+	edited[1] = strings.Replace(edited[1], `"path":"file"`, `"path":"other"`, 1)
+	if records, _, err := verifyLines(edited); err == nil || records != 1 {
+		t.Errorf("edited record: %d records, %v, want an error after 1", records, err)
+	}
+
+	removed := append(append([]string(nil), lines[:1]...), lines[2:]...)
+	if records, _, err := verifyLines(removed); err == nil || records != 1 {
+		t.Errorf("removed record: %d records, %v, want an error after 1", records, err)
+	}
+
+	// A record cut off the end leaves a shorter chain that is still intact,
+	// which only the last hash kept elsewhere reveals
+	if records, got, err := verifyLines(lines[:3]); err != nil || records != 3 || got == last {
+		t.Errorf("truncated log: %d records, last %s, %v", records, got, err)
+	}
+	cut := append(append([]string(nil), lines[:3]...), lines[3][:len(lines[3])/2])
+	if records, _, err := verifyLines(cut); err == nil || records != 3 {
+		t.Errorf("log cut mid-record: %d records, %v, want an error after 3", records, err)
+	}
+
+	// A record forged with a hash of its own does not chain to the one
+	// before it
+	forged, _ := auditChain(t, 2, "other")
+	if records, _, err := verifyLines([]string{lines[0], forged[1]}); err == nil || records != 1 {
+		t.Errorf("record forged with its own hash: %d records, %v, want an error after 1", records, err)
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	lines, _ := auditChain(t, 3, "file")
+	edited := append([]string(nil), lines...)
+	edited[2] = strings.Replace(edited[2], `"outcome":"ok"`, `"outcome":"failed"`, 1)
+
+	dir := t.TempDir()
+	for name, want := range map[string]int{"intact": 0, "edited": 1} {
+		log := lines
+		if name == "edited" {
+			log = edited
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(log, "\n")+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if got := auditCommand([]string{"verify", path}); got != want {
+			t.Errorf("audit verify %s = %d, want %d", name, got, want)
+		}
+	}
+	if got := auditCommand([]string{"verify", filepath.Join(dir, "missing")}); got != 1 {
+		t.Errorf("audit verify of a missing log = %d, want 1", got)
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"file-transfer/client"
+	"file-transfer/encryption"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// dial connects a client to a fresh server and removes the files it leaves
+// behind once the test ends.
+func dial(t *testing.T) *client.Client {
+	t.Helper()
+	c, err := client.Dial(startServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		entries, _ := os.ReadDir(".")
+		for _, entry := range entries {
+			os.RemoveAll(entry.Name())
+		}
+	})
+	return c
+}
+
+func put(t *testing.T, c *client.Client, name string, data []byte) {
+	t.Helper()
+	if err := c.Put(context.Background(), name, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("put %s: %v", name, err)
+	}
+}
+
+func TestClientPutGet(t *testing.T) {
+	c := dial(t)
+	data := bytes.Repeat([]byte("0123456789"), 100000)
+	put(t, c, "dir/file", data)
+
+	var got bytes.Buffer
+	if err := c.Get(context.Background(), "dir/file", &got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Fatalf("got %d bytes back, want the %d stored", got.Len(), len(data))
+	}
+
+	// Chunked uploads take data of unknown length
+	if err := c.Put(context.Background(), "chunked", bytes.NewReader(data), -1); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "chunked")
+	if err := c.GetFile(context.Background(), "chunked", path); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := os.ReadFile(path); err != nil || !bytes.Equal(stored, data) {
+		t.Fatalf("chunked upload came back as %d bytes (%v)", len(stored), err)
+	}
+}
+
+func TestClientStatListDelete(t *testing.T) {
+	c := dial(t)
+	put(t, c, "a", []byte("hello"))
+	put(t, c, "sub/b", []byte("world!"))
+
+	info, err := c.Stat("a", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 5 || len(info.Checksum) != 16 || info.Encrypted {
+		t.Errorf("stat a = %+v", info)
+	}
+
+	entries, err := c.List(".", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make(map[string]uint64)
+	for _, entry := range entries {
+		if !entry.IsDir {
+			sizes[entry.Name] = entry.Size
+		}
+	}
+	if len(sizes) != 2 || sizes["a"] != 5 || sizes["sub/b"] != 6 {
+		t.Errorf("list = %v", sizes)
+	}
+
+	if err := c.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Stat("a", false); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat after delete: %v, want fs.ErrNotExist", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	c := dial(t)
+	put(t, c, "taken", []byte("first"))
+
+	err := c.Put(context.Background(), "taken", bytes.NewReader([]byte("second")), 6)
+	var serverErr *client.ServerError
+	if !errors.Is(err, fs.ErrExist) || !errors.As(err, &serverErr) || serverErr.Request != "storage" {
+		t.Errorf("put over a stored file: %v, want a ServerError matching fs.ErrExist", err)
+	}
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, client.ErrChecksum) {
+		t.Errorf("put over a stored file: %v matches the wrong errors", err)
+	}
+
+	c.Overwrite = true
+	put(t, c, "taken", []byte("second"))
+	c.Overwrite = false
+
+	for name, err := range map[string]error{
+		"get":    c.Get(context.Background(), "missing", &bytes.Buffer{}),
+		"delete": c.Delete("missing"),
+		"rename": c.Rename("missing", "elsewhere"),
+	} {
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s of a missing file: %v, want fs.ErrNotExist", name, err)
+		}
+	}
+
+	// Error messages that merely mention a condition do not match it
+	put(t, c, "file exists", []byte("x"))
+	if _, err := c.Stat("file exists/no such file or directory", false); errors.Is(err, fs.ErrExist) {
+		t.Errorf("stat below a file: %v matches fs.ErrExist", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestChecksumMismatch sends data with the wrong checksum, which the client
+// library itself never does.
+func TestChecksumMismatch(t *testing.T) {
+	conn, err := net.Dial("tcp", startServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgHandler := messages.NewMessageHandler(conn)
+	defer msgHandler.Close()
+
+	if err := msgHandler.SendStorageRequest("corrupt", 4, nil, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := msgHandler.ReceiveResponse(); !ok {
+		t.Fatal(msg)
+	}
+	if _, err := msgHandler.Write([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := msgHandler.SendChecksumVerification(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := msgHandler.ReceiveResponse(); ok {
+		t.Fatal("corrupt upload was stored")
+	}
+	if code := msgHandler.ErrorCode(); code != messages.ErrorCode_CHECKSUM_MISMATCH {
+		t.Errorf("error code %v, want CHECKSUM_MISMATCH", code)
+	}
+	if _, err := os.Lstat("corrupt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("corrupt upload left behind: %v", err)
+	}
+	if !errors.Is(&client.ServerError{Code: messages.ErrorCode_CHECKSUM_MISMATCH}, client.ErrChecksum) {
+		t.Error("CHECKSUM_MISMATCH does not match client.ErrChecksum")
+	}
+}
+
+// TestEncryptedPutTreeResumes puts an encrypted tree from outside the current
+// directory twice; the second time every file is already stored.
+func TestEncryptedPutTreeResumes(t *testing.T) {
+	c := dial(t)
+	c.Keys = encryption.KeyFromPassphrase("secret")
+	c.Encrypt = true
+
+	root := filepath.Join(t.TempDir(), "tree")
+	for name, data := range map[string]string{"a": "first", "sub/b": "second file"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summary, err := c.PutTree(root, func(client.TreeResult) {})
+	if err != nil || summary.Transferred != 2 || summary.Failed != 0 {
+		t.Fatalf("first put: %+v, %v", summary, err)
+	}
+	summary, err = c.PutTree(root, func(result client.TreeResult) {
+		if !result.Skipped {
+			t.Errorf("%s was not skipped: %v", result.Name, result.Err)
+		}
+	})
+	if err != nil || summary.Skipped != 2 {
+		t.Fatalf("second put: %+v, %v", summary, err)
+	}
+}
+
+// TestEncryptedSwap stores two encrypted files and swaps them behind the
+// client's back, which decryption has to notice.
+func TestEncryptedSwap(t *testing.T) {
+	c := dial(t)
+	c.Keys = encryption.KeyFromPassphrase("secret")
+	c.Encrypt = true
+	put(t, c, "a", []byte("first"))
+	put(t, c, "b", []byte("second"))
+
+	if err := os.Rename("a", "swap"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename("b", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename("swap", "b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b"} {
+		if err := c.Get(context.Background(), name, &bytes.Buffer{}); !errors.Is(err, encryption.ErrTampered) {
+			t.Errorf("get %s after the swap: %v, want ErrTampered", name, err)
+		}
+	}
+
+	if err := c.Rename("a", "c"); !errors.Is(err, client.ErrSealedName) {
+		t.Errorf("rename of an encrypted file: %v, want ErrSealedName", err)
+	}
+}
+
+// cancellingReader reads zeros, calling cancel once after the first n.
+type cancellingReader struct {
+	n      int
+	cancel func()
+}
+
+func (r *cancellingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		r.cancel()
+	} else if len(p) > r.n {
+		p = p[:r.n]
+	}
+	r.n -= len(p)
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestClientReconnects(t *testing.T) {
+	c := dial(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &cancellingReader{n: 1 << 20, cancel: cancel}
+	if err := c.Put(ctx, "cancelled", r, 64<<20); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled put: %v, want context.Canceled", err)
+	}
+	if _, err := os.Lstat("cancelled"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("cancelled upload was stored: %v", err)
+	}
+
+	// The aborted connection is of no further use, so the next transfer
+	// makes a new one
+	data := []byte("after reconnecting")
+	put(t, c, "file", data)
+	var got bytes.Buffer
+	if err := c.Get(context.Background(), "file", &got); err != nil || !bytes.Equal(got.Bytes(), data) {
+		t.Fatalf("got %q (%v), want %q", got.Bytes(), err, data)
+	}
+}
+
+func TestClientProgress(t *testing.T) {
+	c := dial(t)
+	var progress []client.Progress
+	var done []client.TransferStats
+	c.Progress = func(p client.Progress) { progress = append(progress, p) }
+	c.ProgressInterval = 50 * time.Millisecond
+	c.Done = func(stats client.TransferStats) { done = append(done, stats) }
+
+	// Limited to two thirds of the data a second, the put reports progress
+	// several times
+	data := make([]byte, 384<<10)
+	c.Limit = util.NewRateLimiter(256 << 10)
+	put(t, c, "progress", data)
+	if len(progress) < 3 {
+		t.Fatalf("progress reported %d times", len(progress))
+	}
+	for i, p := range progress {
+		if p.Name != "progress" || p.Total != int64(len(data)) || i > 0 && p.Bytes < progress[i-1].Bytes {
+			t.Fatalf("progress %d: %+v", i, p)
+		}
+	}
+	if last := progress[len(progress)-1]; last.Bytes != int64(len(data)) {
+		t.Errorf("finished at %d bytes, want %d", last.Bytes, len(data))
+	}
+	if len(done) != 1 || done[0].Bytes != int64(len(data)) || done[0].Checksum != client.ChecksumVerified || done[0].Err != nil {
+		t.Fatalf("done: %+v", done)
+	}
+
+	done = nil
+	if err := c.Get(context.Background(), "missing", io.Discard); err == nil {
+		t.Fatal("got a missing file")
+	}
+	if len(done) != 1 || done[0].Checksum != client.ChecksumUnchecked || done[0].Err == nil {
+		t.Fatalf("done after a failed get: %+v", done)
+	}
+}
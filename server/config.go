@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"file-transfer/util"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	configFile = flag.String("config", "", "read settings from this file; flags given on the command line take precedence")
+	listenAddr = flag.String("listen", "", "listen on this address, e.g. :9000 or 192.168.1.10:9000")
+	storageDir = flag.String("dir", ".", "store files in this directory")
+)
+
+// A configSetting is one setting from the config file. Settings are named
+// like the flags, without the dash.
+type configSetting struct {
+	key, value string
+	line       int // 0 for JSON files
+}
+
+func (c configSetting) String() string {
+	if c.line > 0 {
+		return fmt.Sprintf("line %d: %s", c.line, c.key)
+	}
+	return c.key
+}
+
+// readConfig reads a config file, either a JSON object or lines of
+// "key = value" with # comments. Values may be quoted, and settings that may
+// be repeated are given as a JSON array or on several lines.
+func readConfig(name string) ([]configSetting, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseJSONConfig(trimmed)
+	}
+
+	var settings []configSetting
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: line %d: expected key = value", name, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("%s: line %d: invalid quoted value", name, line)
+			}
+		}
+		settings = append(settings, configSetting{key: key, value: value, line: line})
+	}
+	return settings, scanner.Err()
+}
+
+func parseJSONConfig(data []byte) ([]configSetting, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+
+	var settings []configSetting
+	var add func(key string, value interface{}) error
+	add = func(key string, value interface{}) error {
+		switch v := value.(type) {
+		case string:
+			settings = append(settings, configSetting{key: key, value: v})
+		case json.Number, bool:
+			settings = append(settings, configSetting{key: key, value: fmt.Sprint(v)})
+		case []interface{}:
+			for _, item := range v {
+				if err := add(key, item); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%s: unsupported value", key)
+		}
+		return nil
+	}
+	for _, key := range sortedKeys(object) {
+		if err := add(key, object[key]); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// commandLineFlags are the flags given on the command line, which the config
+// file does not override. loadedSettings are the settings the config file
+// had at startup, with repeated ones joined by newlines.
+var (
+	commandLineFlags = make(map[string]bool)
+	loadedSettings   map[string]string
+)
+
+func settingValues(settings []configSetting) map[string]string {
+	values := make(map[string]string)
+	for _, setting := range settings {
+		if previous, ok := values[setting.key]; ok {
+			values[setting.key] = previous + "\n" + setting.value
+		} else {
+			values[setting.key] = setting.value
+		}
+	}
+	return values
+}
+
+// loadConfig applies the settings in the config file that the command line
+// does not override, and validates the configuration as a whole.
+func loadConfig() error {
+	flag.Visit(func(f *flag.Flag) {
+		commandLineFlags[f.Name] = true
+	})
+	var errs []error
+	if *configFile != "" {
+		settings, err := readConfig(*configFile)
+		if err != nil {
+			return err
+		}
+		for _, setting := range settings {
+			f := flag.Lookup(setting.key)
+			if f == nil || setting.key == "config" {
+				errs = append(errs, fmt.Errorf("%s: unknown setting", setting))
+			} else if !commandLineFlags[setting.key] {
+				if err := f.Value.Set(setting.value); err != nil {
+					errs = append(errs, fmt.Errorf("%s: invalid value %q: %v", setting, setting.value, err))
+				}
+			}
+		}
+		loadedSettings = settingValues(settings)
+		// Reloading happens after the server has changed directory
+		if *configFile, err = filepath.Abs(*configFile); err != nil {
+			return err
+		}
+	}
+
+	// The port and directory can still be given the old way
+	if flag.NArg() > 2 {
+		errs = append(errs, errors.New("too many arguments"))
+	}
+	if flag.NArg() >= 1 {
+		if commandLineFlags["listen"] {
+			errs = append(errs, errors.New("give either -listen or a port argument"))
+		}
+		*listenAddr = ":" + flag.Arg(0)
+		commandLineFlags["listen"] = true
+	}
+	if flag.NArg() >= 2 {
+		if commandLineFlags["dir"] {
+			errs = append(errs, errors.New("give either -dir or a download-dir argument"))
+		}
+		*storageDir = flag.Arg(1)
+		commandLineFlags["dir"] = true
+	}
+	return errors.Join(append(errs, validateConfig()...)...)
+}
+
+func validateConfig() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	if *listenAddr == "" {
+		errs = append(errs, errors.New("no address to listen on: give -listen or a port"))
+	} else if _, _, err := net.SplitHostPort(*listenAddr); err != nil {
+		errs = append(errs, fmt.Errorf("listen: %v", err))
+	}
+	if *metricsAddr != "" {
+		if _, _, err := net.SplitHostPort(*metricsAddr); err != nil {
+			errs = append(errs, fmt.Errorf("metrics-addr: %v", err))
+		}
+	}
+	if info, err := os.Stat(*storageDir); err != nil {
+		errs = append(errs, fmt.Errorf("dir: %v", err))
+	} else {
+		check(info.IsDir(), "dir: %s is not a directory", *storageDir)
+	}
+
+	for name, value := range map[string]int{
+		"max-connections":        *maxConnections,
+		"max-connections-per-ip": *maxPerIP,
+		"max-transfers":          *maxTransfers,
+	} {
+		check(value >= 0, "%s: must not be negative", name)
+	}
+	check(*maxStreams > 0, "max-streams: must be positive")
+	check(*keepaliveMisses > 0, "keepalive-misses: must be positive")
+	for name, value := range map[string]time.Duration{
+		"handshake-timeout": *handshakeTimeout,
+		"idle-timeout":      *idleTimeout,
+		"queue-timeout":     *queueTimeout,
+	} {
+		check(value > 0, "%s: must be positive", name)
+	}
+	for name, value := range map[string]time.Duration{
+		"stall-timeout":    *stallTimeout,
+		"shutdown-timeout": *shutdownTimeout,
+		"retry-after":      *retryAfter,
+		"keepalive":        *keepalive,
+	} {
+		check(value >= 0, "%s: must not be negative", name)
+	}
+	if err := setupLogging(); err != nil {
+		errs = append(errs, err)
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errs
+}
+
+// The settings a running server picks up when it gets SIGHUP. Each one checks
+// its value and returns a function applying it; the others need a restart.
+var reloadable = map[string]func(value string) (func(), error){
+	"log-level": func(value string) (func(), error) {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(value)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", value)
+		}
+		return func() { level.Set(l) }, nil
+	},
+	"limit-rate":                reloadRate(func(rate int64) { setRateLimits(rate, -1, -1) }),
+	"limit-rate-per-user":       reloadRate(func(rate int64) { setRateLimits(-1, rate, -1) }),
+	"limit-rate-per-connection": reloadRate(func(rate int64) { setRateLimits(-1, -1, rate) }),
+	"max-connections":           reloadCount(maxConnections),
+	"max-connections-per-ip":    reloadCount(maxPerIP),
+}
+
+func reloadRate(set func(rate int64)) func(string) (func(), error) {
+	return func(value string) (func(), error) {
+		if value == "" {
+			value = "0" // the default, no limit
+		}
+		rate, err := util.ParseRate(value)
+		if err != nil {
+			return nil, err
+		}
+		return func() { set(rate) }, nil
+	}
+}
+
+// reloadCount changes a connection limit, which admit reads with the
+// connections locked.
+func reloadCount(limit *int) func(string) (func(), error) {
+	return func(value string) (func(), error) {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid value %q", value)
+		}
+		return func() {
+			connections.Lock()
+			*limit = n
+			connections.Unlock()
+		}, nil
+	}
+}
+
+// reloadConfig applies the reloadable settings of the config file. Settings
+// removed from the file go back to their defaults, and nothing changes if any
+// setting is invalid.
+func reloadConfig() {
+	if *configFile == "" {
+		slog.Warn("Not reloading, no config file given")
+		return
+	}
+	settings, err := readConfig(*configFile)
+	if err != nil {
+		slog.Error("Unable to reload config", "error", err)
+		return
+	}
+
+	// As at startup, the last of repeated settings wins
+	var errs []error
+	latest := make(map[string]string)
+	for _, setting := range settings {
+		if flag.Lookup(setting.key) == nil || setting.key == "config" {
+			errs = append(errs, fmt.Errorf("%s: unknown setting", setting))
+		}
+		latest[setting.key] = setting.value
+	}
+	values := settingValues(settings)
+	for name := range loadedSettings {
+		if _, ok := values[name]; !ok {
+			values[name] = ""
+		}
+	}
+	for _, name := range sortedKeys(values) {
+		if reloadable[name] == nil && flag.Lookup(name) != nil && !commandLineFlags[name] && values[name] != loadedSettings[name] {
+			slog.Warn("Setting changed, restart the server to apply it", "setting", name)
+		}
+	}
+
+	var apply []func()
+	for _, name := range sortedKeys(reloadable) {
+		if commandLineFlags[name] {
+			continue
+		}
+		value, ok := latest[name]
+		if !ok {
+			value = flag.Lookup(name).DefValue
+		}
+		f, err := reloadable[name](value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+		apply = append(apply, f)
+	}
+	if err := errors.Join(errs...); err != nil {
+		slog.Error("Not reloading invalid config", "error", err)
+		return
+	}
+	for _, f := range apply {
+		f()
+	}
+	slog.Info("Reloaded config", "file", *configFile)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keepConfig puts the flags and the state loadConfig keeps back the way they
+// were when the test ends.
+func keepConfig(t *testing.T) {
+	values := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) { values[f.Name] = f.Value.String() })
+	logger, oldLevel := slog.Default(), level.Level()
+	t.Cleanup(func() {
+		flag.VisitAll(func(f *flag.Flag) {
+			if f.Value.String() != values[f.Name] {
+				f.Value.Set(values[f.Name])
+			}
+		})
+		slog.SetDefault(logger)
+		level.Set(oldLevel)
+		commandLineFlags = make(map[string]bool)
+		loadedSettings = nil
+	})
+}
+
+// writeConfig writes a config file of key = value lines and points -config
+// at it.
+func writeConfig(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	*configFile = path
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	keepConfig(t)
+	dir := t.TempDir()
+	writeConfig(t, filepath.Join(dir, "server.conf"),
+		"listen = :9000",
+		"dir = "+filepath.Join(dir, "missing"),
+		"max-connections = -1",
+		"shutdown-timeout = soon",
+		"colour = blue",
+	)
+
+	err := loadConfig()
+	if err == nil {
+		t.Fatal("invalid config was accepted")
+	}
+	// Every problem is reported at once
+	for _, want := range []string{
+		"dir: stat",
+		"max-connections: must not be negative",
+		"line 4: shutdown-timeout: invalid value",
+		"line 5: colour: unknown setting",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	keepConfig(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.conf")
+	writeConfig(t, path, "listen = :9000", "dir = "+dir, "log-level = warn", "max-connections = 5")
+	if err := loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Nothing changes while any reloadable setting is invalid
+	writeConfig(t, path, "listen = :9000", "dir = "+dir, "log-level = loud", "max-connections = 7")
+	reloadConfig()
+	if *maxConnections != 5 || level.Level() != slog.LevelWarn {
+		t.Fatalf("bad config applied: max-connections %d, log level %v", *maxConnections, level.Level())
+	}
+
+	// A setting removed from the file goes back to its default
+	writeConfig(t, path, "listen = :9000", "dir = "+dir, "max-connections = 7")
+	reloadConfig()
+	if *maxConnections != 7 || level.Level() != slog.LevelInfo {
+		t.Fatalf("config not applied: max-connections %d, log level %v", *maxConnections, level.Level())
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"file-transfer/encryption"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var errOutsideSandbox = errors.New("path is outside the storage directory")
+
+// checkPath rejects file names that would reach outside the storage
+// directory the server runs in.
+func checkPath(name string) error {
+	clean := filepath.Clean(name)
+	if name == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s: %w", name, errOutsideSandbox)
+	}
+	return nil
+}
+
+// isPartial reports whether name is one of the temporary files uploads are
+// written to before they are complete.
+func isPartial(name string) bool {
+	base := filepath.Base(name)
+	return strings.HasPrefix(base, ".") && strings.Contains(base, ".part-")
+}
+
+// createUpload makes sure fileName is free, or only holds a file to be
+// overwritten, and returns a temporary file next to it for the upload to go
+// to, creating any missing directories on the way.
+func createUpload(fileName string, overwrite bool) (*os.File, error) {
+	if info, err := os.Lstat(fileName); err == nil && (!overwrite || !info.Mode().IsRegular()) {
+		return nil, &fs.PathError{Op: "open", Path: fileName, Err: syscall.EEXIST}
+	}
+	dir, base := filepath.Split(fileName)
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	temp, err := os.CreateTemp(dir, "."+base+".part-*")
+	if err != nil {
+		return nil, err
+	}
+	// Temporary files are private, but stored files are not
+	if err := temp.Chmod(0644); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return nil, err
+	}
+	return temp, nil
+}
+
+// commitUpload applies the metadata the client sent, if any, and moves a
+// complete upload into place without clobbering a file stored under the same
+// name in the meantime, unless it is to be overwritten. Stored files always
+// stay readable by the server. The checksum of the contents, if known, is
+// kept to speed up later retrievals.
+func commitUpload(logger *slog.Logger, temp *os.File, fileName string, metadata *messages.FileMetadata, checksum []byte, overwrite bool) error {
+	if err := util.ApplyMetadata(temp, metadata, true, 0600); err != nil {
+		return err
+	}
+	if checksum != nil {
+		if err := util.SaveChecksum(temp, checksum); err != nil {
+			logger.Warn("Unable to save checksum", "file", fileName, "error", err)
+		}
+	}
+	if overwrite {
+		return os.Rename(temp.Name(), fileName)
+	}
+	return os.Link(temp.Name(), fileName)
+}
+
+// storedSize returns the size of a stored file as clients see it, which is
+// smaller than the size on disk if the file is sealed at rest.
+func storedSize(path string, info fs.FileInfo) int64 {
+	file, err := os.Open(path)
+	if err != nil {
+		return info.Size()
+	}
+	defer file.Close()
+
+	if !util.IsSealed(file) {
+		return info.Size()
+	}
+	return encryption.PlainSize(info.Size() - encryption.AtRestHeaderSize)
+}
+
+func handleList(s *session, request *messages.ListRequest) error {
+	msgHandler := s.msgHandler
+	root := request.Path
+	if root == "" {
+		root = "."
+	}
+	if err := checkPath(root); err != nil {
+		return msgHandler.SendListResponse(false, msgHandler.Failure(err), nil)
+	}
+	s.log.Info("Listing", "path", root)
+
+	var entries []*messages.FileEntry
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root || isPartial(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entry := &messages.FileEntry{Name: filepath.ToSlash(path), IsDir: d.IsDir()}
+		if d.Type().IsRegular() {
+			entry.Size = uint64(storedSize(path, info))
+		}
+		entries = append(entries, entry)
+
+		if d.IsDir() && !request.Recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return msgHandler.SendListResponse(false, msgHandler.Failure(err), nil)
+	}
+	return msgHandler.SendListResponse(true, "OK", entries)
+}
+
+// handleDelete removes a stored file, or a directory once it is empty.
+func handleDelete(s *session, request *messages.DeleteRequest) error {
+	a := s.audit("delete", request.FileName)
+	defer a.write()
+	s.log.Info("Deleting", "file", request.FileName)
+	if err := checkStoredName(request.FileName); err != nil {
+		return s.msgHandler.SendResponse(false, s.msgHandler.Failure(err))
+	}
+	if info, err := os.Lstat(request.FileName); err == nil && info.Mode().IsRegular() {
+		a.size = storedSize(request.FileName, info)
+	}
+	if err := os.Remove(request.FileName); err != nil {
+		s.log.Warn("Failed to delete", "file", request.FileName, "error", err)
+		return s.msgHandler.SendResponse(false, s.msgHandler.Failure(err))
+	}
+	a.outcome = "ok"
+	return s.msgHandler.SendResponse(true, "Deleted "+request.FileName)
+}
+
+// handleRename moves a stored file or directory to a name that is not taken,
+// creating any missing directories on the way.
+func handleRename(s *session, request *messages.RenameRequest) error {
+	a := s.audit("rename", request.From)
+	a.to = request.To
+	defer a.write()
+	s.log.Info("Renaming", "file", request.From, "to", request.To)
+	for _, name := range []string{request.From, request.To} {
+		if err := checkStoredName(name); err != nil {
+			return s.msgHandler.SendResponse(false, s.msgHandler.Failure(err))
+		}
+	}
+	if err := rename(request.From, request.To); err != nil {
+		s.log.Warn("Failed to rename", "file", request.From, "to", request.To, "error", err)
+		return s.msgHandler.SendResponse(false, s.msgHandler.Failure(err))
+	}
+	a.outcome = "ok"
+	return s.msgHandler.SendResponse(true, "Renamed "+request.From+" to "+request.To)
+}
+
+// checkStoredName rejects names that are outside the storage directory, the
+// storage directory itself, or uploads in progress.
+func checkStoredName(name string) error {
+	if err := checkPath(name); err != nil {
+		return err
+	}
+	if filepath.Clean(name) == "." {
+		return fmt.Errorf("%s: not a stored file", name)
+	}
+	if isPartial(name) {
+		return fmt.Errorf("%s: reserved file name", name)
+	}
+	return nil
+}
+
+func rename(from, to string) error {
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(to); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	if !info.IsDir() {
+		// Linking fails if the new name is taken, so nothing is clobbered
+		if err := os.Link(from, to); err != nil {
+			return err
+		}
+		return os.Remove(from)
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EEXIST}
+	}
+	return os.Rename(from, to)
+}
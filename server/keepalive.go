@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"file-transfer/messages"
+	"time"
+)
+
+// pingClient pings the client of a session every time it has been quiet for
+// interval while waiting for a request, and hangs up once misses pings in a
+// row have gone unanswered. It returns when stop is closed.
+func (s *session) pingClient(stop <-chan struct{}, interval time.Duration, misses int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		switch {
+		case !s.waiting || s.closed || time.Since(s.quietSince) < interval:
+		case s.unanswered >= misses:
+			s.unresponsive = true
+			s.msgHandler.Close()
+		default:
+			s.pingSeq++
+			if err := s.msgHandler.SendPing(s.pingSeq, interval, uint32(misses)); err == nil {
+				s.unanswered++
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// receiveRequest waits for the next request, taking in the answers to pings
+// along the way. A client that sends a request before it sees a ping answers
+// the ping before sending anything else, so those pongs are read before the
+// request is handled, within the deadline already set for the request.
+func (s *session) receiveRequest() (*messages.Wrapper, error) {
+	for {
+		s.mu.Lock()
+		s.waiting = true
+		s.quietSince = time.Now()
+		s.mu.Unlock()
+
+		wrapper, err := s.msgHandler.Receive()
+
+		s.mu.Lock()
+		s.waiting = false
+		if err == nil && wrapper.GetPong() != nil && s.unanswered > 0 {
+			s.unanswered--
+		}
+		pending := s.unanswered
+		s.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if wrapper.GetPong() != nil {
+			continue
+		}
+
+		for ; pending > 0; pending-- {
+			reply, err := s.msgHandler.Receive()
+			if err != nil {
+				return nil, err
+			}
+			if reply.GetPong() == nil {
+				return nil, errors.New("expected the answer to a ping")
+			}
+		}
+		s.mu.Lock()
+		s.unanswered = 0
+		s.mu.Unlock()
+		return wrapper, nil
+	}
+}
+
+func (s *session) isUnresponsive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.unresponsive
+}
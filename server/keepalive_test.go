@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// setKeepalive makes the server ping quiet clients every interval for the
+// rest of the test.
+func setKeepalive(t *testing.T, interval time.Duration, misses int) {
+	oldInterval, oldMisses := *keepalive, *keepaliveMisses
+	*keepalive, *keepaliveMisses = interval, misses
+	t.Cleanup(func() { *keepalive, *keepaliveMisses = oldInterval, oldMisses })
+}
+
+func TestSilentClientDropped(t *testing.T) {
+	setKeepalive(t, 20*time.Millisecond, 2)
+	conn, err := net.Dial("tcp", startServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The pings are read but never answered, long before the handshake
+	// timeout runs out
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Fatalf("connection was not closed: %v", err)
+	}
+}
+
+func TestPingsAnsweredBeforeRequest(t *testing.T) {
+	setKeepalive(t, 20*time.Millisecond, 5)
+	c := dial(t)
+	put(t, c, "file", []byte("data"))
+
+	// Pings go out while the client is quiet, and it answers them when it
+	// next reads
+	time.Sleep(70 * time.Millisecond)
+	var got bytes.Buffer
+	if err := c.Get(context.Background(), "file", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "data" {
+		t.Fatalf("got %q", got.String())
+	}
+	if _, err := c.Stat("file", false); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+var (
+	logLevel  = flag.String("log-level", "info", "log messages at this level and above: debug, info, warn or error")
+	logFormat = flag.String("log-format", "text", "log as text or json")
+)
+
+// The level can change while the server runs, see reloadConfig.
+var level slog.LevelVar
+
+// setLogLevel parses and applies a -log-level setting.
+func setLogLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q", name)
+	}
+	level.Set(l)
+	return nil
+}
+
+// setupLogging sends all logs, including those of the log package, through
+// a slog handler configured by the flags.
+func setupLogging() error {
+	if err := setLogLevel(*logLevel); err != nil {
+		return err
+	}
+	options := &slog.HandlerOptions{Level: &level}
+
+	var handler slog.Handler
+	switch strings.ToLower(*logFormat) {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, options)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, options)
+	default:
+		return fmt.Errorf("invalid log format %q", *logFormat)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// fatal logs an error that keeps the server from running and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// newConnectionID returns a random ID that tells a connection apart in the
+// logs, also across restarts.
+func newConnectionID() string {
+	id := make([]byte, 4)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// nextRequest starts logging for the next request on the session. Its ID is
+// sent back to the client in the responses.
+func (s *session) nextRequest() {
+	s.requests++
+	id := fmt.Sprintf("%s-%d", s.id, s.requests)
+	s.log = s.connLog.With("request", id)
+	s.msgHandler.SetRequestID(id)
+}
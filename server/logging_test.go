@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logBuffer collects log lines from every goroutine of the server.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON log records of connection conn with the message
+// msg.
+func (b *logBuffer) records(t *testing.T, conn, msg string) []map[string]interface{} {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("log line %q: %v", line, err)
+		}
+		if record["conn"] == conn && record["msg"] == msg {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func TestRequestIDs(t *testing.T) {
+	logs := &logBuffer{}
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+	t.Cleanup(func() { os.Remove("logged") })
+
+	msgHandler := connect(t, startServer(t))
+	data := []byte("0123456789abcdef")
+	if err := msgHandler.SendStorageRequest("logged", uint64(len(data)), nil, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := msgHandler.ReceiveResponse(); !ok {
+		t.Fatal(msg)
+	}
+	first := msgHandler.RequestID()
+	if _, err := msgHandler.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	if err := msgHandler.SendChecksumVerification(sum[:]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := msgHandler.ReceiveResponse(); !ok || msgHandler.RequestID() != first {
+		t.Fatalf("got %v %q for request %q, want the answer to %q", ok, msg, msgHandler.RequestID(), first)
+	}
+
+	if err := msgHandler.SendRetrievalRequest("logged"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg, _, _, _ := msgHandler.ReceiveRetrievalResponse(); !ok {
+		t.Fatal(msg)
+	}
+	second := msgHandler.RequestID()
+
+	// Requests on one connection share its ID as a prefix
+	conn, _, _ := strings.Cut(first, "-")
+	if first == "" || second == first || !strings.HasPrefix(second, conn+"-") {
+		t.Fatalf("request IDs %q and %q", first, second)
+	}
+	for msg, request := range map[string]string{
+		"Storing file":    first,
+		"Stored file":     first,
+		"Retrieving file": second,
+	} {
+		records := logs.records(t, conn, msg)
+		if len(records) != 1 || records[0]["request"] != request {
+			t.Errorf("%q logged as %v, want request %s on connection %s", msg, records, request, conn)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bufio"
+	"file-transfer/util"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var metricsAddr = flag.String("metrics-addr", "", "serve Prometheus metrics over HTTP on this address, e.g. :9100 (off by default)")
+
+// Upper bounds of the transfer duration histogram, in seconds.
+var durationBuckets = []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+var metrics = struct {
+	connectionsTotal atomic.Uint64
+	bytesReceived    atomic.Uint64
+	bytesSent        atomic.Uint64
+	activeTransfers  atomic.Int64
+
+	mu               sync.Mutex
+	requests         map[string]uint64    // by request type
+	transfers        map[[2]string]uint64 // by operation and outcome
+	checksumFailures map[string]uint64    // by operation
+	durations        map[string]*histogram
+}{
+	requests:         make(map[string]uint64),
+	transfers:        make(map[[2]string]uint64),
+	checksumFailures: make(map[string]uint64),
+	durations:        make(map[string]*histogram),
+}
+
+func countRequest(kind string) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.requests[kind]++
+}
+
+// A transferMetrics follows one transfer for the metrics. Handlers start one
+// and defer finish, setting outcome once they know how it went.
+type transferMetrics struct {
+	operation string
+	start     time.Time
+	outcome   string
+}
+
+func startTransfer(operation string) *transferMetrics {
+	metrics.activeTransfers.Add(1)
+	return &transferMetrics{operation: operation, start: time.Now(), outcome: "failed"}
+}
+
+func (t *transferMetrics) received(n int64) {
+	metrics.bytesReceived.Add(uint64(n))
+}
+
+func (t *transferMetrics) sent(n int64) {
+	metrics.bytesSent.Add(uint64(n))
+}
+
+func (t *transferMetrics) checksumFailed() {
+	t.outcome = "checksum_mismatch"
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.checksumFailures[t.operation]++
+}
+
+func (t *transferMetrics) finish() {
+	metrics.activeTransfers.Add(-1)
+	seconds := time.Since(t.start).Seconds()
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.transfers[[2]string{t.operation, t.outcome}]++
+	h := metrics.durations[t.operation]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		metrics.durations[t.operation] = h
+	}
+	i := sort.SearchFloat64s(durationBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// countingReader adds everything read through it to the bytes received.
+type countingReader struct {
+	r io.Reader
+	t *transferMetrics
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.t.received(int64(n))
+	return n, err
+}
+
+// countingWriter adds everything written through it to the bytes sent.
+type countingWriter struct {
+	w io.Writer
+	t *transferMetrics
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.t.sent(int64(n))
+	return n, err
+}
+
+// serveMetrics answers scrapes in the Prometheus text format until the
+// listener fails.
+func serveMetrics(addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("Unable to serve metrics", "error", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+	slog.Info("Serving metrics", "addr", listener.Addr().String())
+	go func() {
+		slog.Error("Metrics server ended", "error", http.Serve(listener, mux))
+	}()
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	out := bufio.NewWriter(w)
+	writeMetrics(out)
+	out.Flush()
+}
+
+func writeMetrics(w io.Writer) {
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	connections, streams := 0, 0
+	sessions.Lock()
+	for s := range sessions.all {
+		if s.parent == nil {
+			connections++
+		} else {
+			streams++
+		}
+	}
+	sessions.Unlock()
+	metric("filetransfer_connections_active", "gauge", "Connections currently open.")
+	fmt.Fprintln(w, "filetransfer_connections_active", connections)
+	metric("filetransfer_streams_active", "gauge", "Streams currently open on multiplexed connections.")
+	fmt.Fprintln(w, "filetransfer_streams_active", streams)
+	metric("filetransfer_connections_total", "counter", "Connections accepted.")
+	fmt.Fprintln(w, "filetransfer_connections_total", metrics.connectionsTotal.Load())
+	metric("filetransfer_transfers_active", "gauge", "Transfers in progress.")
+	fmt.Fprintln(w, "filetransfer_transfers_active", metrics.activeTransfers.Load())
+	metric("filetransfer_received_bytes_total", "counter", "File data received from clients.")
+	fmt.Fprintln(w, "filetransfer_received_bytes_total", metrics.bytesReceived.Load())
+	metric("filetransfer_sent_bytes_total", "counter", "File data sent to clients.")
+	fmt.Fprintln(w, "filetransfer_sent_bytes_total", metrics.bytesSent.Load())
+
+	metrics.mu.Lock()
+	metric("filetransfer_requests_total", "counter", "Requests received, by type.")
+	for _, kind := range sortedKeys(metrics.requests) {
+		fmt.Fprintf(w, "filetransfer_requests_total{type=%q} %d\n", kind, metrics.requests[kind])
+	}
+
+	metric("filetransfer_transfers_total", "counter", "Transfers finished, by operation and outcome.")
+	var keys [][2]string
+	for key := range metrics.transfers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "filetransfer_transfers_total{operation=%q,outcome=%q} %d\n", key[0], key[1], metrics.transfers[key])
+	}
+
+	metric("filetransfer_checksum_failures_total", "counter", "Transfers whose data did not match its checksum, by operation.")
+	for _, operation := range sortedKeys(metrics.checksumFailures) {
+		fmt.Fprintf(w, "filetransfer_checksum_failures_total{operation=%q} %d\n", operation, metrics.checksumFailures[operation])
+	}
+
+	metric("filetransfer_transfer_duration_seconds", "histogram", "How long transfers took, by operation.")
+	for _, operation := range sortedKeys(metrics.durations) {
+		h := metrics.durations[operation]
+		cumulative := uint64(0)
+		for i, count := range h.counts {
+			cumulative += count
+			le := "+Inf"
+			if i < len(durationBuckets) {
+				le = fmt.Sprint(durationBuckets[i])
+			}
+			fmt.Fprintf(w, "filetransfer_transfer_duration_seconds_bucket{operation=%q,le=%q} %d\n", operation, le, cumulative)
+		}
+		fmt.Fprintf(w, "filetransfer_transfer_duration_seconds_sum{operation=%q} %g\n", operation, h.sum)
+		fmt.Fprintf(w, "filetransfer_transfer_duration_seconds_count{operation=%q} %d\n", operation, h.count)
+	}
+	metrics.mu.Unlock()
+
+	// The server runs in its storage directory
+	if total, available, err := util.DiskSpace("."); err == nil {
+		metric("filetransfer_disk_size_bytes", "gauge", "Size of the filesystem files are stored on.")
+		fmt.Fprintln(w, "filetransfer_disk_size_bytes", total)
+		metric("filetransfer_disk_available_bytes", "gauge", "Space left for stored files.")
+		fmt.Fprintln(w, "filetransfer_disk_available_bytes", available)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// requestType names a request for the metrics, e.g. "StorageReq".
+func requestType(msg interface{}) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", msg), "*messages.Wrapper_")
+}
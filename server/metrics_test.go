@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"file-transfer/client"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape fetches the metrics through the HTTP handler and returns every
+// sample by its name and labels.
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handleMetrics(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("Content-Type %q", contentType)
+	}
+
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample %q: %v", line, err)
+		}
+		samples[line[:i]] = value
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	addr := startServer(t)
+	before := scrape(t)
+
+	c, err := client.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove("metrics") })
+	data := bytes.Repeat([]byte("metrics"), 1000)
+	if err := c.Put(context.Background(), "metrics", bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := c.Get(context.Background(), "metrics", &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server counts a transfer once it has answered it
+	retrieved := `filetransfer_transfers_total{operation="retrieve",outcome="ok"}`
+	after := scrape(t)
+	for i := 0; i < 100 && after[retrieved] == before[retrieved]; i++ {
+		time.Sleep(10 * time.Millisecond)
+		after = scrape(t)
+	}
+	delta := func(sample string) float64 { return after[sample] - before[sample] }
+	for sample, want := range map[string]float64{
+		"filetransfer_connections_total":                                             1,
+		"filetransfer_received_bytes_total":                                          float64(len(data)),
+		"filetransfer_sent_bytes_total":                                              float64(len(data)),
+		`filetransfer_requests_total{type="StorageReq"}`:                             1,
+		`filetransfer_requests_total{type="RetrievalReq"}`:                           1,
+		`filetransfer_transfers_total{operation="store",outcome="ok"}`:               1,
+		`filetransfer_transfers_total{operation="retrieve",outcome="ok"}`:            1,
+		`filetransfer_transfer_duration_seconds_count{operation="store"}`:            1,
+		`filetransfer_transfer_duration_seconds_bucket{operation="store",le="+Inf"}`: 1,
+	} {
+		if got := delta(sample); got != want {
+			t.Errorf("%s went up by %v, want %v", sample, got, want)
+		}
+	}
+	if after["filetransfer_transfers_active"] != 0 {
+		t.Errorf("%v transfers still active", after["filetransfer_transfers_active"])
+	}
+
+	// Buckets are cumulative and end with every observation
+	for _, operation := range []string{"store", "retrieve"} {
+		previous := 0.0
+		for _, le := range append(formatBuckets(), "+Inf") {
+			sample := `filetransfer_transfer_duration_seconds_bucket{operation="` + operation + `",le="` + le + `"}`
+			count, ok := after[sample]
+			if !ok {
+				t.Fatalf("missing %s", sample)
+			}
+			if count < previous {
+				t.Errorf("%s is %v, less than the bucket below", sample, count)
+			}
+			previous = count
+		}
+		count := after[`filetransfer_transfer_duration_seconds_count{operation="`+operation+`"}`]
+		if previous != count {
+			t.Errorf("+Inf bucket of %s is %v, want the count %v", operation, previous, count)
+		}
+	}
+}
+
+func formatBuckets() []string {
+	var les []string
+	for _, bound := range durationBuckets {
+		les = append(les, strconv.FormatFloat(bound, 'g', -1, 64))
+	}
+	return les
+}
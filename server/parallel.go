@@ -0,0 +1,288 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// A parallel upload collects ranges sent over several connections in a
+// temporary file. The connection that started it commits the file once the
+// client sends the checksum of the whole thing, and waits for it as long as
+// data keeps arriving for some range.
+type upload struct {
+	file    *os.File
+	size    int64
+	control *messages.MessageHandler
+	timeout time.Duration
+
+	mu       sync.Mutex
+	claimed  []span // ranges being sent or stored
+	received int64
+	done     bool
+}
+
+type span struct {
+	start, end int64
+}
+
+// claim reserves a range for one connection to send. Ranges must not
+// overlap, so that the ranges stored add up to the whole file exactly when
+// all of it has been sent.
+func (u *upload) claim(r span) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for _, c := range u.claimed {
+		if r.start < c.end && c.start < r.end {
+			return false
+		}
+	}
+	u.claimed = append(u.claimed, r)
+	return true
+}
+
+// unclaim gives up a range that could not be stored, so it can be sent again.
+func (u *upload) unclaim(r span) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i, c := range u.claimed {
+		if c == r {
+			u.claimed = append(u.claimed[:i], u.claimed[i+1:]...)
+			return
+		}
+	}
+}
+
+// progressed gives the connection waiting for the checksum more time.
+func (u *upload) progressed() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.done {
+		u.control.SetReadDeadline(time.Now().Add(u.timeout))
+	}
+}
+
+// A progressReader reports every read of range data to its upload.
+type progressReader struct {
+	r io.Reader
+	u *upload
+}
+
+func (p progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.u.progressed()
+	}
+	return n, err
+}
+
+var uploadsMutex sync.Mutex
+var uploads = make(map[string]*upload)
+
+func handleParallelStorage(s *session, request *messages.StorageRequest) error {
+	msgHandler := s.msgHandler
+	s.log.Info("Storing file in parts", "file", request.FileName, "size", request.Size, "parts", request.Parts)
+	a := s.audit("store", request.FileName)
+	defer a.write()
+	if err := checkStoredName(request.FileName); err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	temp, err := createUpload(request.FileName, request.Overwrite)
+	if err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	defer os.Remove(temp.Name())
+	defer temp.Close()
+	if err := temp.Truncate(int64(request.Size)); err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+
+	idBytes := make([]byte, 16)
+	rand.Read(idBytes)
+	id := hex.EncodeToString(idBytes)
+	u := &upload{file: temp, size: int64(request.Size), control: msgHandler, timeout: transferTimeout()}
+	uploadsMutex.Lock()
+	uploads[id] = u
+	uploadsMutex.Unlock()
+	defer func() {
+		uploadsMutex.Lock()
+		delete(uploads, id)
+		uploadsMutex.Unlock()
+	}()
+
+	// Nothing more happens on this connection until all ranges are in
+	msgHandler.SetReadDeadline(time.Now().Add(u.timeout))
+	defer msgHandler.SetReadDeadline(time.Time{})
+	if err := msgHandler.SendTransferResponse(true, "Ready for ranges", id); err != nil {
+		return err
+	}
+	clientCheck, err := msgHandler.ReceiveChecksum()
+	u.mu.Lock()
+	u.done = true
+	received := u.received
+	u.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if received != u.size {
+		s.log.Warn("Failed to store file: upload incomplete", "file", request.FileName, "received", received, "size", u.size)
+		return msgHandler.SendResponse(false, "Upload incomplete")
+	}
+
+	md5 := md5.New()
+	if _, err := io.Copy(md5, io.NewSectionReader(temp, 0, u.size)); err != nil {
+		s.log.Error("Unable to read upload", "file", request.FileName, "error", err)
+		return msgHandler.SendResponse(false, "Unable to read upload")
+	}
+	serverCheck := md5.Sum(nil)
+	if !util.VerifyChecksum(serverCheck, clientCheck) {
+		s.log.Warn("Failed to store file: invalid checksum", "file", request.FileName)
+		a.outcome = "checksum_mismatch"
+		return msgHandler.SendResponse(false, msgHandler.Failure(messages.ErrInvalidChecksum))
+	}
+	a.size, a.checksum = u.size, serverCheck
+
+	if err := commitParallelUpload(s.log, temp, request, serverCheck); err != nil {
+		s.log.Warn("Failed to store file", "file", request.FileName, "error", err)
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	s.log.Info("Stored file", "file", request.FileName)
+	a.outcome = "ok"
+	return msgHandler.SendResponse(true, "File stored")
+}
+
+// Ranges arrive out of order, so with encryption at rest a parallel upload
+// can only be sealed once it is complete.
+func commitParallelUpload(logger *slog.Logger, temp *os.File, request *messages.StorageRequest, checksum []byte) error {
+	fileName, size := request.FileName, int64(request.Size)
+	if len(masterKeys) == 0 {
+		return commitUpload(logger, temp, fileName, request.Metadata, checksum, request.Overwrite)
+	}
+
+	sealed, err := createUpload(fileName, request.Overwrite)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sealed.Name())
+	defer sealed.Close()
+	enc, err := seal(sealed)
+	if err == nil {
+		_, err = io.Copy(enc, io.NewSectionReader(temp, 0, size))
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return commitUpload(logger, sealed, fileName, request.Metadata, checksum, request.Overwrite)
+}
+
+func handleRangeStorage(s *session, request *messages.RangeStorageRequest) error {
+	msgHandler := s.msgHandler
+	t := startTransfer("store_range")
+	defer t.finish()
+
+	uploadsMutex.Lock()
+	u := uploads[request.TransferId]
+	uploadsMutex.Unlock()
+	if u == nil {
+		return msgHandler.SendResponse(false, "Unknown transfer")
+	}
+	end := request.Offset + request.Length
+	if end < request.Offset || end > uint64(u.size) {
+		return msgHandler.SendResponse(false, "Range out of bounds")
+	}
+	r := span{int64(request.Offset), int64(end)}
+	if !u.claim(r) {
+		return msgHandler.SendResponse(false, "Range overlaps one already sent")
+	}
+	stored := false
+	defer func() {
+		if !stored {
+			u.unclaim(r)
+		}
+	}()
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	defer release()
+
+	if err := msgHandler.SendResponse(true, "Ready for data"); err != nil {
+		return err
+	}
+	md5 := md5.New()
+	w := io.MultiWriter(util.NewOffsetWriter(u.file, int64(request.Offset)), md5)
+	data := progressReader{s.throttle.Reader(countingReader{msgHandler, t}), u}
+	if _, err := io.CopyN(w, data, int64(request.Length)); err != nil {
+		return err
+	}
+
+	clientCheck, err := receiveChecksum(msgHandler)
+	if err != nil {
+		return err
+	}
+	if !util.VerifyChecksum(md5.Sum(nil), clientCheck) {
+		t.checksumFailed()
+		return msgHandler.SendResponse(false, msgHandler.Failure(messages.ErrInvalidChecksum))
+	}
+
+	u.mu.Lock()
+	u.received += int64(request.Length)
+	u.mu.Unlock()
+	stored = true
+	t.outcome = "ok"
+	return msgHandler.SendResponse(true, "Range stored")
+}
+
+func handleRangeRetrieval(s *session, request *messages.RangeRetrievalRequest) error {
+	msgHandler := s.msgHandler
+	t := startTransfer("retrieve_range")
+	defer t.finish()
+	a := s.audit("retrieve_range", request.FileName)
+	defer a.write()
+
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendRetrievalResponse(false, msgHandler.Failure(err), 0, nil, false)
+	}
+	defer release()
+
+	file, contents, size, err := openStored(request.FileName, int64(request.Offset))
+	if err != nil {
+		s.log.Warn("Failed to retrieve range", "file", request.FileName, "error", err)
+		return msgHandler.SendRetrievalResponse(false, msgHandler.Failure(err), 0, nil, false)
+	}
+	defer file.Close()
+
+	end := request.Offset + request.Length
+	if end < request.Offset || end > uint64(size) {
+		return msgHandler.SendRetrievalResponse(false, "Range out of bounds", 0, nil, false)
+	}
+	if err := msgHandler.SendRetrievalResponse(true, "Ready to send", request.Length, nil, false); err != nil {
+		return err
+	}
+
+	md5 := md5.New()
+	w := io.MultiWriter(msgHandler, md5)
+	n, err := s.throttle.CopyN(w, contents, int64(request.Length))
+	t.sent(n)
+	if err != nil {
+		return err
+	}
+	a.size, a.checksum = n, md5.Sum(nil)
+	if err := msgHandler.SendChecksumVerification(a.checksum); err != nil {
+		return err
+	}
+	t.outcome, a.outcome = "ok", "ok"
+	return nil
+}
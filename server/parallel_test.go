@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/md5"
+	"errors"
+	"file-transfer/messages"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// connect opens a raw connection to the server at addr.
+func connect(t *testing.T, addr string) *messages.MessageHandler {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgHandler := messages.NewMessageHandler(conn)
+	t.Cleanup(msgHandler.Close)
+	return msgHandler
+}
+
+// startParallel starts a parallel upload of size bytes and returns its ID.
+func startParallel(t *testing.T, msgHandler *messages.MessageHandler, name string, size uint64) string {
+	t.Helper()
+	if err := msgHandler.SendParallelStorageRequest(name, size, 2, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	ok, msg, id := msgHandler.ReceiveTransferResponse()
+	if !ok {
+		t.Fatal(msg)
+	}
+	return id
+}
+
+// sendRange sends data as the range at offset and returns the server's
+// answer.
+func sendRange(t *testing.T, msgHandler *messages.MessageHandler, id string, offset uint64, data []byte) (bool, string) {
+	t.Helper()
+	if err := msgHandler.SendRangeStorageRequest(id, offset, uint64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := msgHandler.ReceiveResponse(); !ok {
+		return ok, msg
+	}
+	if _, err := msgHandler.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	if err := msgHandler.SendChecksumVerification(sum[:]); err != nil {
+		t.Fatal(err)
+	}
+	return msgHandler.ReceiveResponse()
+}
+
+func TestParallelMissingRange(t *testing.T) {
+	addr := startServer(t)
+	control, ranges := connect(t, addr), connect(t, addr)
+	data := []byte("0123456789abcdef")
+	id := startParallel(t, control, "parallel", uint64(len(data)))
+
+	if ok, msg := sendRange(t, ranges, id, 0, data[:8]); !ok {
+		t.Fatal(msg)
+	}
+	// Sending part of the first range again must not make up for the
+	// second one
+	if ok, _ := sendRange(t, ranges, id, 4, data[4:12]); ok {
+		t.Fatal("overlapping range was accepted")
+	}
+
+	sum := md5.Sum(data)
+	if err := control.SendChecksumVerification(sum[:]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := control.ReceiveResponse(); ok || msg != "Upload incomplete" {
+		t.Fatalf("got %v %q, want Upload incomplete", ok, msg)
+	}
+	if _, err := os.Lstat("parallel"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("incomplete upload was stored: %v", err)
+	}
+}
+
+func TestParallelAbandoned(t *testing.T) {
+	old := *stallTimeout
+	*stallTimeout = 50 * time.Millisecond
+	addr := startServer(t)
+	control := connect(t, addr)
+	startParallel(t, control, "abandoned", 16)
+	*stallTimeout = old
+
+	// No range ever arrives, so the server stops waiting for the checksum
+	done := make(chan error, 1)
+	go func() {
+		_, err := control.Receive()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v, want the connection closed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is still waiting for the abandoned upload")
+	}
+}
+
+func TestParallelSlowRanges(t *testing.T) {
+	old := *stallTimeout
+	*stallTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { *stallTimeout = old })
+	addr := startServer(t)
+	control, ranges := connect(t, addr), connect(t, addr)
+	t.Cleanup(func() { os.Remove("slow") })
+	data := []byte("0123456789abcdef")
+	id := startParallel(t, control, "slow", uint64(len(data)))
+
+	// All ranges together take longer than the stall timeout, but each one
+	// gives the waiting connection more time
+	for offset := 0; offset < len(data); offset += 8 {
+		time.Sleep(70 * time.Millisecond)
+		if ok, msg := sendRange(t, ranges, id, uint64(offset), data[offset:offset+8]); !ok {
+			t.Fatal(msg)
+		}
+	}
+	sum := md5.Sum(data)
+	if err := control.SendChecksumVerification(sum[:]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := control.ReceiveResponse(); !ok {
+		t.Fatal(msg)
+	}
+}
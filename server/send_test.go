@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/md5"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkSend sends a file over loopback TCP the way handleRetrieval does,
+// either copying it through an MD5 hash or, when the checksum is already
+// known, straight from the file with ReadFrom.
+func benchmarkSend(b *testing.B, size int64, zeroCopy bool) {
+	path := filepath.Join(b.TempDir(), "file")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		b.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer file.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		if peer, err := listener.Accept(); err == nil {
+			io.Copy(io.Discard, peer)
+			peer.Close()
+		}
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	msgHandler := messages.NewMessageHandler(conn)
+	var throttle util.Throttle
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			b.Fatal(err)
+		}
+		if zeroCopy {
+			_, err = throttle.CopyN(msgHandler, file, size)
+		} else {
+			md5 := md5.New()
+			_, err = throttle.CopyN(io.MultiWriter(msgHandler, md5), file, size)
+			md5.Sum(nil)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSendCopy(b *testing.B)     { benchmarkSend(b, 64<<20, false) }
+func BenchmarkSendZeroCopy(b *testing.B) { benchmarkSend(b, 64<<20, true) }
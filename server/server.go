@@ -2,117 +2,678 @@ package main
 
 import (
 	"crypto/md5"
+	"errors"
+	"file-transfer/encryption"
 	"file-transfer/messages"
 	"file-transfer/util"
+	"flag"
 	"fmt"
+	"hash"
 	"io"
-	"log"
+	"io/fs"
+	"log/slog"
+	"math"
 	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 )
 
-func handleStorage(msgHandler *messages.MessageHandler, request *messages.StorageRequest) {
-	log.Println("Attempting to store", request.FileName)
-	file, err := os.OpenFile(request.FileName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+// When encryption at rest is enabled, new files are sealed with the first
+// key and files sealed with any of the others can still be read.
+var masterKeys []*encryption.MasterKey
+
+// seal starts encrypting file at rest with the current master key. The file
+// is marked as sealed rather than recognized by its contents, which clients
+// control.
+func seal(file *os.File) (*encryption.ChunkWriter, error) {
+	if err := util.MarkSealed(file); err != nil {
+		return nil, err
+	}
+	return encryption.SealFile(file, masterKeys[0])
+}
+
+// checkSealing makes sure files in the storage directory can be marked as
+// sealed.
+func checkSealing() error {
+	file, err := os.CreateTemp(".", ".seal-check-*")
 	if err != nil {
-		msgHandler.SendResponse(false, err.Error())
-		msgHandler.Close()
-		return
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if err := util.MarkSealed(file); err != nil {
+		return fmt.Errorf("encryption at rest needs extended attributes: %w", err)
+	}
+	return nil
+}
+
+var (
+	handshakeTimeout = flag.Duration("handshake-timeout", 30*time.Second, "close new connections that send no request for this long")
+	idleTimeout      = flag.Duration("idle-timeout", 5*time.Minute, "close connections that send nothing for this long")
+	stallTimeout     = flag.Duration("stall-timeout", time.Minute, "abort transfers whose data makes no progress for this long (0 disables)")
+	shutdownTimeout  = flag.Duration("shutdown-timeout", 30*time.Second, "on SIGTERM or SIGINT, wait this long for transfers in progress to finish")
+	keepalive        = flag.Duration("keepalive", time.Minute, "ping clients that have been quiet for this long (0 disables)")
+	keepaliveMisses  = flag.Int("keepalive-misses", 3, "close connections once this many pings in a row go unanswered")
+)
+
+// transferTimeout is how long a transfer may wait for the client: the stall
+// timeout, or the idle timeout if stalls are not detected.
+func transferTimeout() time.Duration {
+	if *stallTimeout == 0 {
+		return *idleTimeout
+	}
+	return *stallTimeout
+}
+
+// receiveChecksum waits for the checksum a client sends right after the data
+// of a transfer. A client that goes quiet instead is given up on after the
+// transfer timeout rather than holding on to the upload and its transfer
+// slot.
+func receiveChecksum(msgHandler *messages.MessageHandler) ([]byte, error) {
+	msgHandler.SetReadDeadline(time.Now().Add(transferTimeout()))
+	defer msgHandler.SetReadDeadline(time.Time{})
+	return msgHandler.ReceiveChecksum()
+}
+
+// handleStorage and handleRetrieval report an error only if the connection
+// can no longer be used; failures the client is told about are not errors.
+func handleStorage(s *session, request *messages.StorageRequest) error {
+	if request.Encrypted && (request.Parts > 1 || request.Chunked || request.Sparse) {
+		return s.msgHandler.SendResponse(false, "Encrypted files must be sent whole over one connection")
+	}
+	if request.Parts > 1 {
+		return handleParallelStorage(s, request)
+	}
+	msgHandler := s.msgHandler
+	t := startTransfer("store")
+	defer t.finish()
+	a := s.audit("store", request.FileName)
+	defer a.write()
+
+	s.log.Info("Storing file", "file", request.FileName, "size", request.Size, "chunked", request.Chunked, "sparse", request.Sparse)
+	if err := checkStoredName(request.FileName); err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	if request.Sparse {
+		if err := checkExtents(request.Extents, request.Size); err != nil {
+			return msgHandler.SendResponse(false, msgHandler.Failure(err))
+		}
+	}
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	defer release()
+
+	// The file only appears under its name once it has been verified
+	file, err := createUpload(request.FileName, request.Overwrite)
+	if err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+	if request.Encrypted {
+		if err := util.MarkEncrypted(file); err != nil {
+			s.log.Error("Unable to mark file as encrypted", "error", err)
+			return msgHandler.SendResponse(false, "Unable to store encrypted files here")
+		}
+	}
+
+	var w io.Writer = file
+	var enc *encryption.ChunkWriter
+	if len(masterKeys) > 0 {
+		enc, err = seal(file)
+		if err != nil {
+			s.log.Error("Unable to encrypt file", "error", err)
+			return msgHandler.SendResponse(false, "Unable to encrypt file")
+		}
+		w = enc
 	}
 
-	msgHandler.SendResponse(true, "Ready for data")
+	if err := msgHandler.SendResponse(true, "Ready for data"); err != nil {
+		return err
+	}
 	md5 := md5.New()
-	w := io.MultiWriter(file, md5)
-	io.CopyN(w, msgHandler, int64(request.Size)) /* Write and checksum as we go */
-	file.Close()
+	w = io.MultiWriter(w, md5)
+	data := s.throttle.Reader(countingReader{msgHandler, t})
+	size := int64(request.Size) // chunked uploads only announce it by ending
+	if request.Sparse {
+		if err := receiveSparse(data, request, file, enc, md5); err != nil {
+			return err
+		}
+	} else if request.Chunked {
+		if size, err = io.Copy(w, messages.NewChunkedReader(data)); err != nil {
+			return err
+		}
+	} else if _, err := io.CopyN(w, data, int64(request.Size)); err != nil { /* Write and checksum as we go */
+		return err
+	}
+	if enc != nil {
+		enc.Close()
+	}
 
 	serverCheck := md5.Sum(nil)
+	clientCheck, err := receiveChecksum(msgHandler)
+	if err != nil {
+		return err
+	}
+
+	if !util.VerifyChecksum(serverCheck, clientCheck) {
+		s.log.Warn("Failed to store file: invalid checksum", "file", request.FileName)
+		t.checksumFailed()
+		a.outcome = "checksum_mismatch"
+		return msgHandler.SendResponse(false, msgHandler.Failure(messages.ErrInvalidChecksum))
+	}
+	a.size, a.checksum = size, serverCheck
+	if err := commitUpload(s.log, file, request.FileName, request.Metadata, serverCheck, request.Overwrite); err != nil {
+		s.log.Warn("Failed to store file", "file", request.FileName, "error", err)
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	s.log.Info("Stored file", "file", request.FileName)
+	t.outcome, a.outcome = "ok", "ok"
+	return msgHandler.SendResponse(true, "File stored")
+}
 
-	clientCheckMsg, _ := msgHandler.Receive()
-	clientCheck := clientCheckMsg.GetChecksum().Checksum
+// checkExtents makes sure the extents of a sparse upload are in order and
+// within the file.
+func checkExtents(extents []*messages.Extent, size uint64) error {
+	offset := uint64(0)
+	for _, extent := range extents {
+		end := extent.Offset + extent.Length
+		if extent.Offset < offset || end < extent.Offset || end > size {
+			return errors.New("invalid extent")
+		}
+		offset = end
+	}
+	return nil
+}
 
-	if util.VerifyChecksum(serverCheck, clientCheck) {
-		log.Println("Successfully stored file.")
-	} else {
-		log.Println("FAILED to store file. Invalid checksum.")
+// receiveSparse reads the extents of a sparse upload and leaves holes in file
+// between them. Files sealed at rest cannot have holes, so for those the
+// holes are written out as zeros. The checksum covers the holes either way.
+func receiveSparse(data io.Reader, request *messages.StorageRequest, file *os.File, enc *encryption.ChunkWriter, md5 hash.Hash) error {
+	fillHole := func(n uint64) error {
+		if enc != nil {
+			return util.WriteZeros(io.MultiWriter(enc, md5), int64(n))
+		}
+		return util.WriteZeros(md5, int64(n))
+	}
+
+	offset := uint64(0)
+	for _, extent := range request.Extents {
+		if err := fillHole(extent.Offset - offset); err != nil {
+			return err
+		}
+		var w io.Writer = enc
+		if enc == nil {
+			w = util.NewOffsetWriter(file, int64(extent.Offset))
+		}
+		if _, err := io.CopyN(io.MultiWriter(w, md5), data, int64(extent.Length)); err != nil {
+			return err
+		}
+		offset = extent.Offset + extent.Length
+	}
+	if err := fillHole(request.Size - offset); err != nil {
+		return err
+	}
+	if enc == nil {
+		return file.Truncate(int64(request.Size))
+	}
+	return nil
+}
+
+// openStored opens a stored file for reading from offset onwards, decrypting
+// it if it was sealed at rest. It returns the size of the whole (decrypted)
+// file, and a reader that is the file itself unless it is sealed.
+func openStored(fileName string, offset int64) (*os.File, io.Reader, int64, error) {
+	if err := checkPath(fileName); err != nil {
+		return nil, nil, 0, err
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, 0, err
+	}
+
+	if !util.IsSealed(file) {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return nil, nil, 0, err
+		}
+		return file, file, info.Size(), nil
+	}
+
+	// Files sealed at rest are decrypted on the way out
+	reader, err := encryption.OpenFileAt(file, offset, masterKeys...)
+	if err != nil {
+		file.Close()
+		return nil, nil, 0, fmt.Errorf("unable to decrypt file: %w", err)
 	}
+	return file, reader, encryption.PlainSize(info.Size() - encryption.AtRestHeaderSize), nil
 }
 
-func handleRetrieval(msgHandler *messages.MessageHandler, request *messages.RetrievalRequest) {
-	log.Println("Attempting to retrieve", request.FileName)
+func handleRetrieval(s *session, request *messages.RetrievalRequest) error {
+	msgHandler := s.msgHandler
+	s.log.Info("Retrieving file", "file", request.FileName)
+	t := startTransfer("retrieve")
+	defer t.finish()
+	a := s.audit("retrieve", request.FileName)
+	defer a.write()
+
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendRetrievalResponse(false, msgHandler.Failure(err), 0, nil, false)
+	}
+	defer release()
 
 	// Get file size and make sure it exists
-	info, err := os.Stat(request.FileName)
+	file, contents, size, err := openStored(request.FileName, 0)
 	if err != nil {
-		log.Fatalln(err)
+		s.log.Warn("Failed to retrieve file", "file", request.FileName, "error", err)
+		return msgHandler.SendRetrievalResponse(false, msgHandler.Failure(err), 0, nil, false)
 	}
+	defer file.Close()
 
-	msgHandler.SendRetrievalResponse(true, "Ready to send", uint64(info.Size()))
+	metadata, err := util.ReadMetadata(request.FileName, true)
+	if err != nil {
+		s.log.Warn("Unable to read metadata", "file", request.FileName, "error", err)
+	}
+	if err := msgHandler.SendRetrievalResponse(true, "Ready to send", uint64(size), metadata, util.IsEncrypted(file)); err != nil {
+		return err
+	}
 
-	file, _ := os.Open(request.FileName)
-	md5 := md5.New()
-	w := io.MultiWriter(msgHandler, md5)
-	io.CopyN(w, file, info.Size()) // Checksum and transfer file at same time
-	file.Close()
+	start := time.Now()
+	checksum := util.StoredChecksum(file)
+	zeroCopy := checksum != nil && contents == io.Reader(file)
+	if zeroCopy {
+		// With the checksum known the file can go straight to the
+		// connection, without passing through this process
+		n, err := s.throttle.CopyN(msgHandler, file, size)
+		t.sent(n)
+		if err != nil {
+			return err
+		}
+	} else {
+		md5 := md5.New()
+		w := io.MultiWriter(msgHandler, md5)
+		n, err := s.throttle.CopyN(w, contents, size) // Checksum and transfer file at same time
+		t.sent(n)
+		if err != nil {
+			return err
+		}
+		checksum = md5.Sum(nil)
+		if err := util.SaveChecksum(file, checksum); err != nil {
+			s.log.Warn("Unable to save checksum", "file", request.FileName, "error", err)
+		}
+	}
+	logThroughput(s.log, request.FileName, size, time.Since(start), zeroCopy)
+	a.size, a.checksum = size, checksum
 
-	checksum := md5.Sum(nil)
-	msgHandler.SendChecksumVerification(checksum)
+	if err := msgHandler.SendChecksumVerification(checksum); err != nil {
+		return err
+	}
+	t.outcome, a.outcome = "ok", "ok"
+	return nil
 }
 
-func handleClient(msgHandler *messages.MessageHandler) {
+func logThroughput(logger *slog.Logger, fileName string, size int64, elapsed time.Duration, zeroCopy bool) {
+	method := "copied"
+	if zeroCopy {
+		method = "zero-copy"
+	}
+	rate := float64(size) / 1e6 / elapsed.Seconds()
+	logger.Info("Sent file", "file", fileName, "bytes", size, "elapsed", elapsed.Round(time.Millisecond),
+		"mb_per_second", math.Round(rate*10)/10, "method", method)
+}
+
+func handleStat(s *session, request *messages.StatRequest) error {
+	msgHandler := s.msgHandler
+	file, contents, size, err := openStored(request.FileName, 0)
+	if err != nil {
+		return msgHandler.SendStatResponse(false, msgHandler.Failure(err), 0, nil, nil, false)
+	}
+	defer file.Close()
+
+	var checksum []byte
+	if request.Checksum {
+		checksum = util.StoredChecksum(file)
+	}
+	if request.Checksum && checksum == nil {
+		md5 := md5.New()
+		if _, err := io.CopyN(md5, contents, size); err != nil {
+			s.log.Error("Unable to read file", "file", request.FileName, "error", err)
+			return msgHandler.SendStatResponse(false, "Unable to read file", 0, nil, nil, false)
+		}
+		checksum = md5.Sum(nil)
+		if err := util.SaveChecksum(file, checksum); err != nil {
+			s.log.Warn("Unable to save checksum", "file", request.FileName, "error", err)
+		}
+	}
+	metadata, err := util.ReadMetadata(request.FileName, true)
+	if err != nil {
+		s.log.Warn("Unable to read metadata", "file", request.FileName, "error", err)
+	}
+	return msgHandler.SendStatResponse(true, "OK", uint64(size), checksum, metadata, util.IsEncrypted(file))
+}
+
+// serveMux switches a connection to multiplexed mode and runs a separate
+// session for every stream the client opens on it.
+func serveMux(s *session) {
+	msgHandler := s.msgHandler
+	if err := msgHandler.SendResponse(true, "Multiplexing"); err != nil {
+		s.log.Info("Closing connection", "error", err)
+		return
+	}
+	s.log.Info("Switched to multiplexed mode")
+
+	mux := messages.NewMux(msgHandler, true)
+	mux.SetIdleTimeout(*idleTimeout)
+	mux.Keepalive(*keepalive, *keepaliveMisses)
+	defer mux.Close()
+	s.mu.Lock()
+	s.mux = mux
+	s.mu.Unlock()
+
+	// Every stream is a session of its own, so each one beyond the first
+	// counts against the connection limits as well as the cap on streams per
+	// connection. The first is covered by the connection itself.
+	streams := make(chan struct{}, *maxStreams)
+	own := make(chan struct{}, 1)
+	for {
+		stream, err := mux.Accept()
+		if err != nil {
+			s.connLog.Info("Multiplexed connection ended", "error", err)
+			return
+		}
+		select {
+		case streams <- struct{}{}:
+		default:
+			s.log.Warn("Too many streams, refusing stream")
+			refuse(stream, "too many streams on one connection")
+			continue
+		}
+		var release func()
+		select {
+		case own <- struct{}{}:
+			release = func() { <-own }
+		default:
+			var ok bool
+			if release, ok = admit(s.addr); !ok {
+				<-streams
+				s.log.Warn("Too many connections, refusing stream")
+				refuse(stream, busyMessage())
+				continue
+			}
+		}
+		streamHandler := messages.NewMessageHandler(stream)
+		streamHandler.SetStallTimeout(*stallTimeout)
+		go func() {
+			defer func() { <-streams }()
+			defer release()
+			handleClient(newSession(streamHandler, s.addr, s))
+		}()
+	}
+}
+
+// handleClient serves requests until the client says goodbye, disconnects,
+// stops answering pings, or stays quiet for longer than idleTimeout (or
+// handshakeTimeout, before the first request). Once the server is shutting
+// down it hangs up after the request in progress.
+func handleClient(s *session) {
+	msgHandler := s.msgHandler
+	defer s.remove()
 	defer msgHandler.Close()
+	if s.parent == nil {
+		metrics.connectionsTotal.Add(1)
+	}
+
+	// Streams are kept alive by their multiplexed connection
+	if s.parent == nil && *keepalive > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go s.pingClient(stop, *keepalive, *keepaliveMisses)
+	}
 
+	timeout := *handshakeTimeout
 	for {
-		wrapper, err := msgHandler.Receive()
+		msgHandler.SetReadDeadline(time.Now().Add(timeout))
+		timeout = *idleTimeout
+		wrapper, err := s.receiveRequest()
+		var goodbye *messages.GoodbyeError
 		if err != nil {
-			log.Println(err)
+			if errors.As(err, &goodbye) {
+				s.connLog.Info("Client said goodbye")
+			} else if s.isUnresponsive() {
+				s.connLog.Info("Client stopped answering pings, closing connection")
+			} else if s.isClosed() {
+				s.connLog.Info("Closed connection for shutdown")
+			} else if errors.Is(err, os.ErrDeadlineExceeded) {
+				s.connLog.Info("Client idle or unresponsive for too long, closing connection")
+			} else if errors.Is(err, io.EOF) {
+				s.connLog.Info("Client disconnected")
+			} else {
+				s.connLog.Warn("Closing connection", "error", err)
+			}
+			return
+		}
+		msgHandler.SetReadDeadline(time.Time{})
+		s.nextRequest()
+		s.log.Debug("Received request", "type", requestType(wrapper.Msg))
+		countRequest(requestType(wrapper.Msg))
+		if !s.start(describe(wrapper)) {
+			return
 		}
 
 		switch msg := wrapper.Msg.(type) {
 		case *messages.Wrapper_StorageReq:
-			handleStorage(msgHandler, msg.StorageReq)
-			continue
+			err = handleStorage(s, msg.StorageReq)
 		case *messages.Wrapper_RetrievalReq:
-			handleRetrieval(msgHandler, msg.RetrievalReq)
-			continue
+			err = handleRetrieval(s, msg.RetrievalReq)
+		case *messages.Wrapper_RangeStorageReq:
+			err = handleRangeStorage(s, msg.RangeStorageReq)
+		case *messages.Wrapper_RangeRetrievalReq:
+			err = handleRangeRetrieval(s, msg.RangeRetrievalReq)
+		case *messages.Wrapper_StatReq:
+			err = handleStat(s, msg.StatReq)
+		case *messages.Wrapper_ListReq:
+			err = handleList(s, msg.ListReq)
+		case *messages.Wrapper_TarStorageReq:
+			err = handleTarStorage(s, msg.TarStorageReq)
+		case *messages.Wrapper_TarRetrievalReq:
+			err = handleTarRetrieval(s, msg.TarRetrievalReq)
+		case *messages.Wrapper_DeleteReq:
+			err = handleDelete(s, msg.DeleteReq)
+		case *messages.Wrapper_RenameReq:
+			err = handleRename(s, msg.RenameReq)
+		case *messages.Wrapper_SetRateLimits:
+			err = handleSetRateLimits(s, msg.SetRateLimits)
+		case *messages.Wrapper_Ping:
+			// A client that stops pinging is gone, no need to wait out the
+			// idle timeout
+			expected := time.Duration(msg.Ping.Interval) * time.Duration(msg.Ping.Misses+1)
+			if msg.Ping.Interval > 0 && msg.Ping.Misses > 0 && expected < timeout {
+				timeout = expected
+			}
+			err = msgHandler.SendPong(msg.Ping.Seq)
+		case *messages.Wrapper_MuxStart:
+			if s.parent == nil {
+				serveMux(s)
+				return
+			}
+			err = msgHandler.SendResponse(false, "Already multiplexed")
 		case nil:
-			log.Println("Received an empty message, terminating client")
+			s.log.Warn("Received an empty message, terminating client")
 			return
 		default:
-			log.Printf("Unexpected message type: %T", msg)
+			s.log.Warn("Unexpected message", "type", requestType(msg))
+			err = msgHandler.SendResponse(false, "Unexpected message")
+		}
+
+		if err != nil {
+			s.log.Warn("Closing connection", "error", err)
+			return
+		}
+		if !s.finish() {
+			s.connLog.Info("Closed connection for shutdown")
+			return
 		}
 	}
 }
 
+// serve accepts connections and serves each one in its own session until
+// the listener is closed.
+func serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+		release, ok := admit(conn.RemoteAddr())
+		if !ok {
+			slog.Warn("Too many connections, refusing", "client", conn.RemoteAddr().String())
+			refuse(conn, busyMessage())
+			continue
+		}
+		handler := messages.NewMessageHandler(conn)
+		handler.SetStallTimeout(*stallTimeout)
+		s := newSession(handler, conn.RemoteAddr(), nil)
+		s.connLog.Info("Accepted connection")
+		go func() {
+			defer release()
+			handleClient(s)
+		}()
+	}
+}
+
+// rotateKeys rewraps the data key of every file under dir that was sealed
+// with an old master key so that it uses the current one.
+func rotateKeys(dir string) error {
+	rotated := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		file, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if !util.IsSealed(file) {
+			return nil
+		}
+		changed, err := encryption.Rewrap(file, masterKeys[0], masterKeys[1:]...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if changed {
+			rotated++
+		}
+		return nil
+	})
+	slog.Info("Rotated master key", "files", rotated)
+	return err
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Printf("Not enough arguments. Usage: %s port [download-dir]\n", os.Args[0])
+	flag.Usage = func() {
+		fmt.Printf("Usage: %s [flags] [port [download-dir]]\n", os.Args[0])
+		fmt.Printf("       %s audit verify audit-log\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	masterKeyFile := flag.String("master-key", "", "encrypt stored files at rest with the key in this file")
+	flag.Func("old-master-key", "previous master key to rotate away from (may be repeated)", func(path string) error {
+		mk, err := encryption.LoadMasterKey(path)
+		if err == nil {
+			masterKeys = append(masterKeys, mk)
+		}
+		return err
+	})
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(auditCommand(os.Args[2:]))
+	}
+	flag.Parse()
+
+	if err := loadConfig(); err != nil {
+		fmt.Printf("Invalid configuration:\n%v\n", err)
 		os.Exit(1)
 	}
 
-	port := os.Args[1]
-	listener, err := net.Listen("tcp", ":"+port)
+	initTransferSlots()
+	if *metricsAddr != "" {
+		serveMetrics(*metricsAddr)
+	}
+
+	if *masterKeyFile != "" {
+		mk, err := encryption.LoadMasterKey(*masterKeyFile)
+		if err != nil {
+			fatal("Unable to load master key", "error", err)
+		}
+		masterKeys = append([]*encryption.MasterKey{mk}, masterKeys...)
+	} else if len(masterKeys) > 0 {
+		fatal("-old-master-key requires -master-key")
+	}
+
+	listener, err := net.Listen("tcp", *listenAddr)
 	if err != nil {
-		log.Fatalln(err.Error())
-		os.Exit(1)
+		fatal("Unable to listen", "error", err)
 	}
 	defer listener.Close()
 
-	dir := "."
-	if len(os.Args) >= 3 {
-		dir = os.Args[2]
+	// The audit log is named relative to where the server was started
+	if *auditLogFile != "" {
+		if err := openAuditLog(*auditLogFile); err != nil {
+			fatal("Unable to open audit log", "error", err)
+		}
+	}
+
+	if err := os.Chdir(*storageDir); err != nil {
+		fatal("Unable to use download directory", "error", err)
+	}
+
+	if len(masterKeys) > 0 {
+		if err := checkSealing(); err != nil {
+			fatal("Unable to encrypt files at rest", "error", err)
+		}
 	}
-	if err := os.Chdir(dir); err != nil {
-		log.Fatalln(err)
+	if len(masterKeys) > 1 {
+		if err := rotateKeys("."); err != nil {
+			fatal("Unable to rotate master key", "error", err)
+		}
 	}
 
-	fmt.Println("Listening on port:", port)
-	fmt.Println("Download directory:", dir)
-	for {
-		if conn, err := listener.Accept(); err == nil {
-			log.Println("Accepted connection", conn.RemoteAddr())
-			handler := messages.NewMessageHandler(conn)
-			go handleClient(handler)
+	// The first signal stops accepting connections and lets transfers in
+	// progress finish, a second one cuts them off
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		slog.Info("Shutting down", "signal", sig.String())
+		listener.Close()
+	}()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			reloadConfig()
 		}
+	}()
+
+	fmt.Println("Listening on:", listener.Addr())
+	fmt.Println("Download directory:", *storageDir)
+	serve(listener)
+
+	if interrupted := shutdown(*shutdownTimeout, signals); interrupted > 0 {
+		slog.Error("Shut down, interrupting transfers", "interrupted", interrupted)
+		os.Exit(1)
 	}
+	slog.Info("Shut down cleanly")
 }
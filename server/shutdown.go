@@ -0,0 +1,260 @@
+package main
+
+import (
+	"file-transfer/messages"
+	"file-transfer/util"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const shutdownNotice = "server shutting down"
+
+// A session is a connection, or a stream of a multiplexed one, that requests
+// are served on. The registry of sessions lets the server wait for transfers
+// in progress before shutting down.
+type session struct {
+	msgHandler *messages.MessageHandler
+	addr       net.Addr
+	parent     *session
+	id         string
+
+	// Every line logged for the connection carries its ID, and while a
+	// request is served also the request's
+	connLog  *slog.Logger
+	log      *slog.Logger
+	requests int
+
+	// Streams share the limits of their connection
+	throttle        util.Throttle
+	connectionLimit *util.RateLimiter
+
+	mu      sync.Mutex
+	busy    string // what the session is doing, or "" while it waits for a request
+	closed  bool
+	mux     *messages.Mux
+	streams int
+	opened  int // streams so far, for their IDs
+
+	// Keepalive pings go out while the session waits for a request
+	waiting      bool
+	quietSince   time.Time
+	pingSeq      uint64
+	unanswered   int
+	unresponsive bool
+}
+
+var sessions = struct {
+	sync.Mutex
+	all          map[*session]bool
+	shuttingDown bool
+	done         sync.WaitGroup
+}{all: make(map[*session]bool)}
+
+func newSession(msgHandler *messages.MessageHandler, addr net.Addr, parent *session) *session {
+	s := &session{msgHandler: msgHandler, addr: addr, parent: parent}
+	if parent != nil {
+		s.throttle, s.connectionLimit = parent.throttle, parent.connectionLimit
+		parent.mu.Lock()
+		parent.opened++
+		s.id = fmt.Sprintf("%s.%d", parent.id, parent.opened)
+		parent.mu.Unlock()
+	} else {
+		s.throttle, s.connectionLimit = connectionThrottle(addr)
+		s.id = newConnectionID()
+	}
+	s.connLog = slog.With("conn", s.id, "client", addr.String())
+	s.log = s.connLog
+	sessions.Lock()
+	defer sessions.Unlock()
+	sessions.all[s] = true
+	sessions.done.Add(1)
+	if parent != nil {
+		parent.mu.Lock()
+		parent.streams++
+		parent.mu.Unlock()
+	}
+	if sessions.shuttingDown {
+		s.hangUp()
+	}
+	return s
+}
+
+func (s *session) remove() {
+	sessions.Lock()
+	defer sessions.Unlock()
+	delete(sessions.all, s)
+	sessions.done.Done()
+	if s.parent != nil {
+		s.parent.mu.Lock()
+		s.parent.streams--
+		s.parent.mu.Unlock()
+	} else {
+		releaseThrottle(s.addr)
+	}
+}
+
+// start marks the session busy with a request. It reports false if the
+// session was closed for shutdown in the meantime.
+func (s *session) start(activity string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.busy = activity
+	return true
+}
+
+// finish marks the session idle again. It reports false, after telling the
+// client, if the server is shutting down.
+func (s *session) finish() bool {
+	s.mu.Lock()
+	s.busy = ""
+	s.mu.Unlock()
+
+	sessions.Lock()
+	defer sessions.Unlock()
+	if sessions.shuttingDown {
+		s.hangUp()
+		return false
+	}
+	return true
+}
+
+func (s *session) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// hangUp tells an idle client that the server is going away and closes the
+// session. Busy sessions are left alone to finish their transfer first, and
+// multiplexed connections until their streams are done.
+func (s *session) hangUp() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.busy != "" {
+		return
+	}
+	if s.mux != nil {
+		if s.streams == 0 {
+			s.closed = true
+			s.mux.Close()
+		}
+		return
+	}
+	s.closed = true
+	s.msgHandler.SendGoodbye(shutdownNotice)
+	s.msgHandler.Close()
+}
+
+// describe says what a request is about, for reporting interrupted transfers.
+func describe(wrapper *messages.Wrapper) string {
+	switch msg := wrapper.Msg.(type) {
+	case *messages.Wrapper_StorageReq:
+		return "storing " + msg.StorageReq.FileName
+	case *messages.Wrapper_RetrievalReq:
+		return "retrieving " + msg.RetrievalReq.FileName
+	case *messages.Wrapper_RangeStorageReq:
+		return "storing part of a parallel upload"
+	case *messages.Wrapper_RangeRetrievalReq:
+		return "retrieving part of " + msg.RangeRetrievalReq.FileName
+	case *messages.Wrapper_TarStorageReq:
+		return "unpacking an archive into " + msg.TarStorageReq.Path
+	case *messages.Wrapper_TarRetrievalReq:
+		return "archiving " + msg.TarRetrievalReq.Path
+	case *messages.Wrapper_DeleteReq:
+		return "deleting " + msg.DeleteReq.FileName
+	case *messages.Wrapper_RenameReq:
+		return "renaming " + msg.RenameReq.From
+	case *messages.Wrapper_MuxStart:
+		return ""
+	default:
+		return "answering a request"
+	}
+}
+
+// shutdown closes idle sessions, gives the busy ones until timeout (or until
+// force is signalled) to finish, then cuts off whatever is left and removes
+// the temporary files of interrupted uploads. It returns the number of
+// transfers that had to be interrupted.
+func shutdown(timeout time.Duration, force <-chan os.Signal) int {
+	sessions.Lock()
+	sessions.shuttingDown = true
+	sessions.Unlock()
+
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		sessions.Lock()
+		remaining := len(sessions.all)
+		for s := range sessions.all {
+			s.hangUp()
+		}
+		sessions.Unlock()
+		if remaining == 0 {
+			break
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case <-deadline:
+			slog.Warn("Timed out waiting for transfers to finish")
+		case <-force:
+			slog.Warn("Not waiting for transfers to finish")
+		}
+		break
+	}
+
+	interrupted := 0
+	sessions.Lock()
+	for s := range sessions.all {
+		s.mu.Lock()
+		if s.busy != "" {
+			s.log.Warn("Interrupted transfer", "activity", s.busy)
+			interrupted++
+		}
+		s.closed = true
+		s.msgHandler.Close()
+		s.mu.Unlock()
+	}
+	sessions.Unlock()
+
+	// Closing the connections makes the remaining handlers give up and
+	// remove their uploads, which should not take long
+	waited := make(chan struct{})
+	go func() {
+		sessions.done.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		slog.Warn("Some sessions did not end in time")
+	}
+
+	removeTemporaryFiles(".")
+	return interrupted
+}
+
+// removeTemporaryFiles deletes leftover partial uploads under dir.
+func removeTemporaryFiles(dir string) {
+	removed := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() && isPartial(path) && os.Remove(path) == nil {
+			removed++
+		}
+		return nil
+	})
+	if removed > 0 {
+		slog.Info("Removed temporary files", "files", removed)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/md5"
+	"errors"
+	"file-transfer/messages"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startShutdown shuts the server down in the background, letting transfers
+// run for timeout, and returns the number it interrupted. New servers accept
+// connections again once the test ends.
+func startShutdown(t *testing.T, timeout time.Duration) <-chan int {
+	t.Cleanup(func() {
+		sessions.Lock()
+		sessions.shuttingDown = false
+		sessions.Unlock()
+	})
+	interrupted := make(chan int, 1)
+	go func() { interrupted <- shutdown(timeout, nil) }()
+	return interrupted
+}
+
+// startUpload sends the first half of data as an upload under name.
+func startUpload(t *testing.T, msgHandler *messages.MessageHandler, name string, data []byte) {
+	t.Helper()
+	if err := msgHandler.SendStorageRequest(name, uint64(len(data)), nil, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := msgHandler.ReceiveResponse(); !ok {
+		t.Fatal(msg)
+	}
+	if _, err := msgHandler.Write(data[:len(data)/2]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	addr := startServer(t)
+	idle, busy := connect(t, addr), connect(t, addr)
+	t.Cleanup(func() { os.Remove("drained") })
+	data := []byte("0123456789abcdef")
+	startUpload(t, busy, "drained", data)
+	interrupted := startShutdown(t, 5*time.Second)
+
+	// Idle clients are told right away
+	var goodbye *messages.GoodbyeError
+	if _, err := idle.Receive(); !errors.As(err, &goodbye) || goodbye.Reason != shutdownNotice {
+		t.Fatalf("idle client got %v, want a goodbye", err)
+	}
+
+	// The upload in progress gets to finish first
+	if _, err := busy.Write(data[len(data)/2:]); err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(data)
+	if err := busy.SendChecksumVerification(sum[:]); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := busy.ReceiveResponse(); !ok {
+		t.Fatal(msg)
+	}
+	if _, err := busy.Receive(); !errors.As(err, &goodbye) {
+		t.Fatalf("client got %v after its upload, want a goodbye", err)
+	}
+	if n := <-interrupted; n != 0 {
+		t.Fatalf("%d transfers interrupted", n)
+	}
+	if got, err := os.ReadFile("drained"); err != nil || string(got) != string(data) {
+		t.Fatalf("stored %q (%v), want %q", got, err, data)
+	}
+}
+
+func TestShutdownInterrupts(t *testing.T) {
+	busy := connect(t, startServer(t))
+	startUpload(t, busy, "interrupted", []byte("0123456789abcdef"))
+	interrupted := startShutdown(t, 100*time.Millisecond)
+
+	// The upload never finishes, so it is cut off and its temporary file
+	// removed
+	select {
+	case n := <-interrupted:
+		if n != 1 {
+			t.Fatalf("%d transfers interrupted, want 1", n)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("shutdown did not give up on the transfer")
+	}
+	filepath.WalkDir(".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && (isPartial(path) || path == "interrupted") {
+			t.Errorf("%s left behind", path)
+		}
+		return nil
+	})
+}
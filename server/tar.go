@@ -0,0 +1,325 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/md5"
+	"errors"
+	"file-transfer/encryption"
+	"file-transfer/messages"
+	"file-transfer/util"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var errUnsupportedEntry = errors.New("only regular files and directories are supported")
+
+// entryPath returns where the tar entry name is unpacked to under root, or
+// "" for the root itself. Names may not leave root, and no directory on the
+// way may be a symlink.
+func entryPath(root, name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("%s: %w", name, errOutsideSandbox)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	if isPartial(clean) {
+		return "", fmt.Errorf("%s: reserved file name", name)
+	}
+
+	full := filepath.Join(root, filepath.FromSlash(clean))
+	if err := checkPath(full); err != nil {
+		return "", err
+	}
+	for dir := filepath.Dir(full); dir != "."; dir = filepath.Dir(dir) {
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("%s: path contains a symlink", name)
+		}
+	}
+	return full, nil
+}
+
+// A tarUnpacker stores the files of an archive as uploads, which are only
+// moved into place once the checksum of the whole archive has been verified.
+// Directories are only created then, too.
+type tarUnpacker struct {
+	root    string
+	log     *slog.Logger
+	entries uint32
+	errors  []*messages.TarEntryError
+	dirs    []string
+	pending map[string]string // file name to upload
+}
+
+func (u *tarUnpacker) fail(name string, err error) {
+	u.log.Warn("Skipping tar entry", "entry", name, "error", err)
+	u.errors = append(u.errors, &messages.TarEntryError{Name: name, Message: err.Error()})
+}
+
+// unpack reads the archive, recording entries it cannot store. It returns an
+// error only if the archive itself is broken.
+func (u *tarUnpacker) unpack(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := entryPath(u.root, header.Name)
+		if err != nil {
+			u.fail(header.Name, err)
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if name != "" {
+				u.dirs = append(u.dirs, name)
+			}
+		case tar.TypeReg:
+			err = u.store(name, header, tr)
+		default:
+			err = fmt.Errorf("%s: %w", header.Name, errUnsupportedEntry)
+		}
+		if err != nil {
+			u.fail(header.Name, err)
+			continue
+		}
+		u.entries++
+	}
+}
+
+func (u *tarUnpacker) store(name string, header *tar.Header, contents io.Reader) error {
+	if _, ok := u.pending[name]; ok {
+		return fmt.Errorf("%s: duplicate entry", header.Name)
+	}
+	temp, err := createUpload(name, false)
+	if err != nil {
+		return err
+	}
+	defer temp.Close()
+	if err := writeEntry(temp, header, contents); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	u.pending[name] = temp.Name()
+	return nil
+}
+
+func writeEntry(temp *os.File, header *tar.Header, contents io.Reader) error {
+	var err error
+	var w io.Writer = temp
+	var enc *encryption.ChunkWriter
+	if len(masterKeys) > 0 {
+		if enc, err = seal(temp); err != nil {
+			return err
+		}
+		w = enc
+	}
+	md5 := md5.New()
+	if _, err := io.Copy(io.MultiWriter(w, md5), contents); err != nil {
+		return err
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	metadata := &messages.FileMetadata{Mode: uint32(header.Mode), Mtime: header.ModTime.UnixNano()}
+	if err := util.ApplyMetadata(temp, metadata, false, 0600); err != nil {
+		return err
+	}
+	return util.SaveChecksum(temp, md5.Sum(nil))
+}
+
+// commit creates the directories and moves every stored file into place,
+// removing its temporary file right away.
+func (u *tarUnpacker) commit() {
+	for _, dir := range u.dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			u.entries--
+			u.fail(filepath.ToSlash(dir), err)
+		}
+	}
+	for name, temp := range u.pending {
+		if err := os.Link(temp, name); err != nil {
+			u.entries--
+			u.fail(filepath.ToSlash(name), err)
+		}
+		os.Remove(temp)
+		delete(u.pending, name)
+	}
+}
+
+func (u *tarUnpacker) cleanup() {
+	for _, temp := range u.pending {
+		os.Remove(temp)
+	}
+}
+
+func handleTarStorage(s *session, request *messages.TarStorageRequest) error {
+	msgHandler := s.msgHandler
+	t := startTransfer("store_tar")
+	defer t.finish()
+	a := s.audit("store_tar", request.Path)
+	defer a.write()
+	s.log.Info("Unpacking archive", "path", request.Path, "size", request.Size)
+	if err := checkPath(request.Path); err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendResponse(false, msgHandler.Failure(err))
+	}
+	defer release()
+	if err := msgHandler.SendResponse(true, "Ready for data"); err != nil {
+		return err
+	}
+
+	md5 := md5.New()
+	body := io.TeeReader(io.LimitReader(s.throttle.Reader(countingReader{msgHandler, t}), int64(request.Size)), md5)
+	u := &tarUnpacker{root: request.Path, log: s.log, pending: make(map[string]string)}
+	defer u.cleanup()
+	unpackErr := u.unpack(body)
+
+	// The rest of the archive has to be consumed even if it was broken
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return err
+	}
+	serverCheck := md5.Sum(nil)
+	clientCheck, err := receiveChecksum(msgHandler)
+	if err != nil {
+		return err
+	}
+
+	if !util.VerifyChecksum(serverCheck, clientCheck) {
+		s.log.Warn("Failed to unpack archive: invalid checksum", "path", request.Path)
+		t.checksumFailed()
+		a.outcome = "checksum_mismatch"
+		return msgHandler.SendTarResponse(false, msgHandler.Failure(messages.ErrInvalidChecksum), 0, 0, nil)
+	}
+	a.size, a.checksum = int64(request.Size), serverCheck
+	if unpackErr != nil {
+		s.log.Warn("Failed to unpack archive", "path", request.Path, "error", unpackErr)
+		return msgHandler.SendTarResponse(false, "Invalid archive: "+unpackErr.Error(), 0, 0, nil)
+	}
+	u.commit()
+	s.log.Info("Unpacked archive", "path", request.Path, "entries", u.entries, "failed", len(u.errors))
+	t.outcome, a.outcome = "ok", "ok"
+	return msgHandler.SendTarResponse(true, "Archive unpacked", 0, u.entries, u.errors)
+}
+
+func handleTarRetrieval(s *session, request *messages.TarRetrievalRequest) error {
+	msgHandler := s.msgHandler
+	t := startTransfer("retrieve_tar")
+	defer t.finish()
+	a := s.audit("retrieve_tar", request.Path)
+	defer a.write()
+	s.log.Info("Archiving", "path", request.Path)
+	if err := checkPath(request.Path); err != nil {
+		return msgHandler.SendTarResponse(false, msgHandler.Failure(err), 0, 0, nil)
+	}
+	if info, err := os.Stat(request.Path); err != nil || !info.IsDir() {
+		return msgHandler.SendTarResponse(false, request.Path+" is not a directory", 0, 0, nil)
+	}
+	release, err := acquireTransfer(s.log)
+	if err != nil {
+		return msgHandler.SendTarResponse(false, msgHandler.Failure(err), 0, 0, nil)
+	}
+	defer release()
+
+	// The archive is planned up front so its size can be announced
+	var headers []*tar.Header
+	var paths []string
+	var skipped []*messages.TarEntryError
+	err = filepath.WalkDir(request.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == request.Path || isPartial(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(request.Path, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !d.IsDir() && !d.Type().IsRegular() {
+			skipped = append(skipped, &messages.TarEntryError{Name: name, Message: errUnsupportedEntry.Error()})
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if d.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Size = storedSize(p, info)
+		}
+		headers = append(headers, header)
+		paths = append(paths, p)
+		return nil
+	})
+	var size int64
+	if err == nil {
+		size, err = util.TarSize(headers)
+	}
+	if err != nil {
+		s.log.Warn("Failed to archive", "path", request.Path, "error", err)
+		return msgHandler.SendTarResponse(false, msgHandler.Failure(err), 0, 0, nil)
+	}
+
+	if err := msgHandler.SendTarResponse(true, "Ready to send", uint64(size), uint32(len(headers)), skipped); err != nil {
+		return err
+	}
+	md5 := md5.New()
+	tw := tar.NewWriter(io.MultiWriter(countingWriter{msgHandler, t}, md5))
+	for i, header := range headers {
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			// Once the size is announced there is no way to report a file
+			// that cannot be sent other than dropping the connection.
+			if err := copyStored(tw, paths[i], header.Size, s.throttle); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	a.size, a.checksum = size, md5.Sum(nil)
+	if err := msgHandler.SendChecksumVerification(a.checksum); err != nil {
+		return err
+	}
+	t.outcome, a.outcome = "ok", "ok"
+	return nil
+}
+
+func copyStored(w io.Writer, fileName string, size int64, throttle util.Throttle) error {
+	file, contents, _, err := openStored(fileName, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = throttle.CopyN(w, contents, size)
+	return err
+}
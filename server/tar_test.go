@@ -0,0 +1,95 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEntryPath(t *testing.T) {
+	// Roots are relative to the storage directory, the current one
+	root := "entries"
+	t.Cleanup(func() { os.RemoveAll(root) })
+	if err := os.MkdirAll(filepath.Join(root, "real"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		".":          "",
+		"a/b":        filepath.Join(root, "a", "b"),
+		"./a//b/":    filepath.Join(root, "a", "b"),
+		"real/file":  filepath.Join(root, "real", "file"),
+		"a/../b":     filepath.Join(root, "b"),
+		"link/../ok": filepath.Join(root, "ok"),
+	} {
+		if got, err := entryPath(root, name); err != nil || got != want {
+			t.Errorf("entryPath(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	for _, name := range []string{"..", "../x", "a/../../x", "/etc/passwd", "link/file", "link/sub/file", ".file.part-1"} {
+		if got, err := entryPath(root, name); err == nil {
+			t.Errorf("entryPath(%q) = %q, want an error", name, got)
+		}
+	}
+	if _, err := entryPath(root, "../x"); !errors.Is(err, errOutsideSandbox) {
+		t.Errorf("entryPath(../x): %v, want errOutsideSandbox", err)
+	}
+}
+
+// TestTarDirectoriesAfterChecksum sends an archive of directories, which are
+// only created once its checksum has been verified.
+func TestTarDirectoriesAfterChecksum(t *testing.T) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for _, name := range []string{"a/", "a/b/"} {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0755}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll("unpacked") })
+
+	msgHandler := connect(t, startServer(t))
+	send := func(checksum []byte) bool {
+		if err := msgHandler.SendTarStorageRequest("unpacked", uint64(archive.Len())); err != nil {
+			t.Fatal(err)
+		}
+		if ok, msg := msgHandler.ReceiveResponse(); !ok {
+			t.Fatal(msg)
+		}
+		if _, err := msgHandler.Write(archive.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if err := msgHandler.SendChecksumVerification(checksum); err != nil {
+			t.Fatal(err)
+		}
+		ok, _, _, _, _ := msgHandler.ReceiveTarResponse()
+		return ok
+	}
+
+	if send(make([]byte, md5.Size)) {
+		t.Fatal("archive with the wrong checksum was unpacked")
+	}
+	if _, err := os.Lstat("unpacked"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("directories created before the checksum was verified: %v", err)
+	}
+
+	sum := md5.Sum(archive.Bytes())
+	if !send(sum[:]) {
+		t.Fatal("archive was not unpacked")
+	}
+	if info, err := os.Stat(filepath.Join("unpacked", "a", "b")); err != nil || !info.IsDir() {
+		t.Fatalf("directory not created: %v", err)
+	}
+}
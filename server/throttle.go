@@ -0,0 +1,119 @@
+package main
+
+import (
+	"file-transfer/messages"
+	"file-transfer/util"
+	"flag"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// Bandwidth is limited overall, per user and per connection, with every
+// transfer on a connection counting against all three. Users are told apart
+// by their address.
+var limits = struct {
+	sync.Mutex
+	global        *util.RateLimiter
+	perUser       int64
+	perConnection int64
+	users         map[string]*userLimit
+}{
+	global: util.NewRateLimiter(0),
+	users:  make(map[string]*userLimit),
+}
+
+type userLimit struct {
+	limiter     *util.RateLimiter
+	connections int
+}
+
+func init() {
+	flag.Func("limit-rate", "limit the bandwidth of all transfers together, e.g. 10M (bytes per second)", func(s string) error {
+		rate, err := util.ParseRate(s)
+		if err != nil {
+			return err
+		}
+		limits.global.SetRate(rate)
+		return nil
+	})
+	flag.Func("limit-rate-per-user", "limit the bandwidth of all transfers from one client address", func(s string) (err error) {
+		limits.perUser, err = util.ParseRate(s)
+		return err
+	})
+	flag.Func("limit-rate-per-connection", "limit the bandwidth of the transfers on one connection", func(s string) (err error) {
+		limits.perConnection, err = util.ParseRate(s)
+		return err
+	})
+}
+
+// connectionThrottle returns the limits that apply to a new connection from
+// addr. The connection's share of its user's limit is given back with
+// releaseThrottle.
+func connectionThrottle(addr net.Addr) (util.Throttle, *util.RateLimiter) {
+	limits.Lock()
+	defer limits.Unlock()
+	user := limits.users[clientIP(addr)]
+	if user == nil {
+		user = &userLimit{limiter: util.NewRateLimiter(limits.perUser)}
+		limits.users[clientIP(addr)] = user
+	}
+	user.connections++
+	connection := util.NewRateLimiter(limits.perConnection)
+	return util.Throttle{limits.global, user.limiter, connection}, connection
+}
+
+func releaseThrottle(addr net.Addr) {
+	limits.Lock()
+	defer limits.Unlock()
+	ip := clientIP(addr)
+	if user := limits.users[ip]; user != nil {
+		if user.connections--; user.connections == 0 {
+			delete(limits.users, ip)
+		}
+	}
+}
+
+// handleSetRateLimits changes the limits at runtime. Only the server's own
+// machine may do that.
+func handleSetRateLimits(s *session, request *messages.SetRateLimits) error {
+	if tcp, ok := s.addr.(*net.TCPAddr); !ok || !tcp.IP.IsLoopback() {
+		s.log.Warn("Refusing to change rate limits remotely")
+		return s.msgHandler.SendResponse(false, "Rate limits can only be changed locally")
+	}
+	description := setRateLimits(request.Global, request.PerUser, request.PerConnection)
+	s.log.Info(description)
+	return s.msgHandler.SendResponse(true, description)
+}
+
+// setRateLimits changes the limits that are not negative, also for the
+// connections already open, and describes the limits now in force.
+func setRateLimits(global, perUser, perConnection int64) string {
+	limits.Lock()
+	if global >= 0 {
+		limits.global.SetRate(global)
+	}
+	if perUser >= 0 {
+		limits.perUser = perUser
+		for _, user := range limits.users {
+			user.limiter.SetRate(perUser)
+		}
+	}
+	if perConnection >= 0 {
+		limits.perConnection = perConnection
+	}
+	description := fmt.Sprintf("Rate limits: %s overall, %s per user, %s per connection",
+		util.FormatRate(limits.global.Rate()), util.FormatRate(limits.perUser), util.FormatRate(limits.perConnection))
+	limits.Unlock()
+
+	if perConnection >= 0 {
+		sessions.Lock()
+		for s := range sessions.all {
+			if s.parent == nil {
+				s.connectionLimit.SetRate(perConnection)
+			}
+		}
+		sessions.Unlock()
+	}
+	return description
+}
@@ -0,0 +1,13 @@
+package util
+
+import "golang.org/x/sys/unix"
+
+// DiskSpace returns the size of the filesystem holding dir and how much of
+// it is available to unprivileged users, in bytes.
+func DiskSpace(dir string) (total uint64, available uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize), nil
+}
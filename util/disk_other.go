@@ -0,0 +1,11 @@
+//go:build !linux
+
+package util
+
+import "errors"
+
+// Disk space is only reported on Linux.
+
+func DiskSpace(dir string) (total uint64, available uint64, err error) {
+	return 0, 0, errors.New("disk space is not supported on this platform")
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"file-transfer/messages"
+	"os"
+	"strings"
+	"time"
+)
+
+// Only user attributes are transferred; the other namespaces are either
+// privileged or describe the local system.
+const xattrPrefix = "user."
+
+// The server keeps what it knows about stored files, beyond their contents,
+// in attributes under this prefix. They are never transferred, and clients
+// cannot set them.
+const internalXattrPrefix = "user.file-transfer."
+
+const (
+	checksumXattr  = internalXattrPrefix + "md5"
+	encryptedXattr = internalXattrPrefix + "e2e"
+	sealedXattr    = internalXattrPrefix + "sealed"
+)
+
+func isTransferable(name string) bool {
+	return strings.HasPrefix(name, xattrPrefix) && !strings.HasPrefix(name, internalXattrPrefix)
+}
+
+// SafeMode strips the setuid, setgid and sticky bits from mode, leaving just
+// the permission bits.
+func SafeMode(mode uint32) os.FileMode {
+	return os.FileMode(mode) & os.ModePerm
+}
+
+// ReadMetadata collects the metadata of the file at path that can be
+// preserved across a transfer.
+func ReadMetadata(path string, xattrs bool) (*messages.FileMetadata, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &messages.FileMetadata{
+		Mode:  uint32(info.Mode().Perm()),
+		Mtime: info.ModTime().UnixNano(),
+	}
+	if xattrs {
+		if metadata.Xattrs, err = getXattrs(path); err != nil {
+			return nil, err
+		}
+	}
+	return metadata, nil
+}
+
+// ApplyMetadata sets the permission bits, modification time and (if xattrs
+// is set) user extended attributes of file. Dangerous mode bits are never
+// applied, and extraMode is added to the mode, e.g. to keep a file readable.
+func ApplyMetadata(file *os.File, metadata *messages.FileMetadata, xattrs bool, extraMode os.FileMode) error {
+	if metadata == nil {
+		return nil
+	}
+	if xattrs {
+		for name, value := range metadata.Xattrs {
+			if !isTransferable(name) {
+				continue
+			}
+			if err := setXattr(file, name, value); err != nil {
+				return err
+			}
+		}
+	}
+	if err := file.Chmod(SafeMode(metadata.Mode) | extraMode); err != nil {
+		return err
+	}
+	mtime := time.Unix(0, metadata.Mtime)
+	return os.Chtimes(file.Name(), mtime, mtime)
+}
+
+// SaveChecksum records the checksum of the contents of file, along with its
+// size and modification time so that it is ignored once the file changes.
+// It has to be called after the modification time has been set.
+func SaveChecksum(file *os.File, checksum []byte) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	value := make([]byte, len(checksum)+16)
+	n := copy(value, checksum)
+	binary.BigEndian.PutUint64(value[n:], uint64(info.Size()))
+	binary.BigEndian.PutUint64(value[n+8:], uint64(info.ModTime().UnixNano()))
+	return setXattr(file, checksumXattr, value)
+}
+
+// StoredChecksum returns the checksum saved by SaveChecksum, or nil if there
+// is none or the file has changed since.
+func StoredChecksum(file *os.File) []byte {
+	value, err := getXattr(file, checksumXattr)
+	if err != nil || len(value) != md5.Size+16 {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	size := binary.BigEndian.Uint64(value[md5.Size:])
+	mtime := binary.BigEndian.Uint64(value[md5.Size+8:])
+	if size != uint64(info.Size()) || mtime != uint64(info.ModTime().UnixNano()) {
+		return nil
+	}
+	return value[:md5.Size]
+}
+
+// MarkEncrypted records that file holds data the client encrypted
+// end-to-end. Without the mark the file would be handed back as plain data,
+// so unlike the checksum it cannot be skipped where extended attributes are
+// not supported.
+func MarkEncrypted(file *os.File) error {
+	return setFlagXattr(file, encryptedXattr)
+}
+
+// IsEncrypted reports whether file was marked by MarkEncrypted.
+func IsEncrypted(file *os.File) bool {
+	_, err := getXattr(file, encryptedXattr)
+	return err == nil
+}
+
+// MarkSealed records that the server encrypted file at rest. Like
+// MarkEncrypted it fails where extended attributes are not supported.
+func MarkSealed(file *os.File) error {
+	return setFlagXattr(file, sealedXattr)
+}
+
+// IsSealed reports whether file was marked by MarkSealed.
+func IsSealed(file *os.File) bool {
+	_, err := getXattr(file, sealedXattr)
+	return err == nil
+}
@@ -0,0 +1,197 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Throttled transfers move data in pieces of at most this size, so that
+// transfers sharing a limit take turns and the rate stays smooth.
+const throttlePiece = 32 * 1024
+
+// Unthrottled copies still go in pieces, if much larger ones, so that a rate
+// set while they run takes effect.
+const unthrottledPiece = 4 * 1024 * 1024
+
+// A RateLimiter is a token bucket holding up to a second's worth of bytes.
+// It is safe to share between transfers, which then split the rate among
+// themselves.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   int64 // bytes per second, 0 for no limit
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter that lets rate bytes per second through.
+// A rate of zero means no limit.
+func NewRateLimiter(rate int64) *RateLimiter {
+	return &RateLimiter{rate: rate, tokens: float64(rate), last: time.Now()}
+}
+
+// SetRate changes the rate, also for transfers already running.
+func (l *RateLimiter) SetRate(rate int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	if l.tokens > float64(rate) {
+		l.tokens = float64(rate)
+	}
+}
+
+func (l *RateLimiter) Rate() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// reserve takes n bytes' worth of tokens, going into debt if need be, and
+// returns how long to wait before the bytes may pass.
+func (l *RateLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.rate <= 0 {
+		l.last = now
+		return 0
+	}
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// A Throttle applies several limits at once, such as a global one and one
+// for the connection. Nil limiters are ignored.
+type Throttle []*RateLimiter
+
+func (t Throttle) limited() bool {
+	for _, l := range t {
+		if l != nil && l.Rate() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wait blocks until n bytes may pass every limit.
+func (t Throttle) wait(n int) {
+	var delay time.Duration
+	for _, l := range t {
+		if l == nil {
+			continue
+		}
+		if d := l.reserve(n); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+type throttledReader struct {
+	r        io.Reader
+	throttle Throttle
+}
+
+// Reader limits the rate at which data can be read from r.
+func (t Throttle) Reader(r io.Reader) io.Reader {
+	for _, l := range t {
+		if l != nil {
+			return &throttledReader{r: r, throttle: t}
+		}
+	}
+	return r
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttlePiece && t.throttle.limited() {
+		p = p[:throttlePiece]
+	}
+	n, err := t.r.Read(p)
+	t.throttle.wait(n)
+	return n, err
+}
+
+// CopyN is io.CopyN within the limits. It copies in pieces rather than
+// wrapping src, so that copying a file to a connection can still use
+// sendfile.
+func (t Throttle) CopyN(dst io.Writer, src io.Reader, n int64) (int64, error) {
+	var written int64
+	for written < n {
+		piece := int64(unthrottledPiece)
+		if t.limited() {
+			piece = throttlePiece
+		}
+		if piece > n-written {
+			piece = n - written
+		}
+		copied, err := io.CopyN(dst, src, piece)
+		written += copied
+		t.wait(int(copied))
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ParseRate parses a rate in bytes per second, such as "500K" or "10M"
+// (multiples of 1024). Zero means no limit.
+func ParseRate(rate string) (int64, error) {
+	s := strings.TrimSpace(strings.ToUpper(rate))
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	bytes := n * float64(multiplier)
+	// NaN fails every comparison, and infinity is too large like anything
+	// else that does not fit in an int64
+	if err != nil || !(n >= 0) || bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid rate %q", rate)
+	}
+	return int64(bytes), nil
+}
+
+// FormatRate describes a rate in the units ParseRate accepts.
+func FormatRate(rate int64) string {
+	if rate <= 0 {
+		return "unlimited"
+	}
+	return FormatSize(rate) + "/s"
+}
+
+// FormatSize describes a number of bytes in the same units.
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return strconv.FormatFloat(float64(size)/(1<<30), 'g', 4, 64) + "G"
+	case size >= 1<<20:
+		return strconv.FormatFloat(float64(size)/(1<<20), 'g', 4, 64) + "M"
+	case size >= 1<<10:
+		return strconv.FormatFloat(float64(size)/(1<<10), 'g', 4, 64) + "K"
+	default:
+		return strconv.FormatInt(size, 10) + "B"
+	}
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The bucket starts with a second's worth of bytes, so moving one and a half
+// seconds' worth takes half a second.
+const testRate = 256 << 10
+
+func checkElapsed(t *testing.T, start time.Time, want time.Duration) {
+	t.Helper()
+	if elapsed := time.Since(start); elapsed < want*8/10 || elapsed > want*3 {
+		t.Errorf("took %v, want about %v", elapsed, want)
+	}
+}
+
+func TestThrottleReader(t *testing.T) {
+	throttle := Throttle{NewRateLimiter(testRate), nil}
+	start := time.Now()
+	n, err := io.Copy(io.Discard, throttle.Reader(bytes.NewReader(make([]byte, testRate*3/2))))
+	if err != nil || n != testRate*3/2 {
+		t.Fatal(n, err)
+	}
+	checkElapsed(t, start, 500*time.Millisecond)
+}
+
+func TestThrottleSlowestLimit(t *testing.T) {
+	throttle := Throttle{NewRateLimiter(100 * testRate), NewRateLimiter(testRate)}
+	start := time.Now()
+	n, err := throttle.CopyN(io.Discard, bytes.NewReader(make([]byte, testRate*3/2)), testRate*3/2)
+	if err != nil || n != testRate*3/2 {
+		t.Fatal(n, err)
+	}
+	checkElapsed(t, start, 500*time.Millisecond)
+}
+
+func TestThrottleShared(t *testing.T) {
+	// Two transfers sharing a limit split the rate between them
+	shared := NewRateLimiter(testRate)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Throttle{shared}.CopyN(io.Discard, bytes.NewReader(make([]byte, testRate*3/4)), testRate*3/4)
+		}()
+	}
+	wg.Wait()
+	checkElapsed(t, start, 500*time.Millisecond)
+
+	// Without a limit nothing waits
+	shared.SetRate(0)
+	start = time.Now()
+	Throttle{shared}.CopyN(io.Discard, bytes.NewReader(make([]byte, 10*testRate)), 10*testRate)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("unlimited copy took %v", elapsed)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for s, want := range map[string]int64{
+		"0":     0,
+		"500":   500,
+		"500k":  500 << 10,
+		" 10M ": 10 << 20,
+		"1.5G":  3 << 29,
+	} {
+		if got, err := ParseRate(s); err != nil || got != want {
+			t.Errorf("ParseRate(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "K", "-1", "ten", "NaN", "Inf", "+Inf", "1e400", "1e19", "9e9G"} {
+		if got, err := ParseRate(s); err == nil {
+			t.Errorf("ParseRate(%q) = %d, want an error", s, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"file-transfer/messages"
+	"io"
+	"os"
+)
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// WriteZeros writes n zero bytes to w, e.g. to checksum the holes of a
+// sparse file.
+func WriteZeros(w io.Writer, n int64) error {
+	_, err := io.CopyN(w, zeroReader{}, n)
+	return err
+}
+
+// DataExtents returns the parts of file that hold data, and whether there
+// are any holes between them at all. Files without holes, or on systems that
+// cannot tell, are reported as not sparse.
+func DataExtents(file *os.File, size int64) ([]*messages.Extent, bool, error) {
+	extents, err := dataExtents(file, size)
+	if err != nil || extents == nil || size == 0 {
+		return nil, false, err
+	}
+	if len(extents) == 1 && extents[0].Offset == 0 && extents[0].Length == uint64(size) {
+		return nil, false, nil
+	}
+	return extents, true, nil
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"errors"
+	"file-transfer/messages"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func dataExtents(file *os.File, size int64) ([]*messages.Extent, error) {
+	defer file.Seek(0, io.SeekStart)
+
+	extents := []*messages.Extent{}
+	for offset := int64(0); offset < size; {
+		data, err := file.Seek(offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// Only holes from here on
+			break
+		}
+		if errors.Is(err, unix.EINVAL) {
+			// The file system does not support finding holes
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		hole, err := file.Seek(data, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		if hole > size {
+			hole = size
+		}
+		if data >= hole {
+			break
+		}
+		extents = append(extents, &messages.Extent{Offset: uint64(data), Length: uint64(hole - data)})
+		offset = hole
+	}
+	return extents, nil
+}
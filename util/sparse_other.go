@@ -0,0 +1,14 @@
+//go:build !linux
+
+package util
+
+import (
+	"file-transfer/messages"
+	"os"
+)
+
+// Holes are only detected on Linux.
+
+func dataExtents(file *os.File, size int64) ([]*messages.Extent, error) {
+	return nil, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package util
+
+import (
+	"os"
+	"time"
+)
+
+// Access times are only reported on Linux.
+
+func AccessTime(info os.FileInfo) time.Time {
+	return time.Time{}
+}
@@ -0,0 +1,31 @@
+package util
+
+import "archive/tar"
+
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// TarSize returns the exact length of a tar archive made of headers and
+// their contents, so the archive can be announced before it is written.
+// Writing the same headers later must produce the same archive layout.
+func TarSize(headers []*tar.Header) (int64, error) {
+	const blockSize = 512
+	size := int64(2 * blockSize) // the two zero blocks ending the archive
+	for _, header := range headers {
+		// Headers can take several blocks with PAX records or long names,
+		// so each is written out to count them. The contents are padded to
+		// whole blocks.
+		counter := &countingWriter{}
+		if err := tar.NewWriter(counter).WriteHeader(header); err != nil {
+			return 0, err
+		}
+		size += counter.n + (header.Size+blockSize-1)/blockSize*blockSize
+	}
+	return size, nil
+}
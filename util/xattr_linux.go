@@ -0,0 +1,66 @@
+package util
+
+import (
+	"bytes"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func getXattrs(path string) (map[string][]byte, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, ignoreUnsupported(err)
+	}
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, ignoreUnsupported(err)
+	}
+
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if !isTransferable(string(name)) {
+			continue
+		}
+		size, err := unix.Getxattr(path, string(name), nil)
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size)
+		size, err = unix.Getxattr(path, string(name), value)
+		if err != nil {
+			return nil, err
+		}
+		xattrs[string(name)] = value[:size]
+	}
+	return xattrs, nil
+}
+
+func getXattr(file *os.File, name string) ([]byte, error) {
+	value := make([]byte, 64)
+	size, err := unix.Fgetxattr(int(file.Fd()), name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value[:size], nil
+}
+
+func setXattr(file *os.File, name string, value []byte) error {
+	return ignoreUnsupported(unix.Fsetxattr(int(file.Fd()), name, value, 0))
+}
+
+// setFlagXattr sets an attribute whose presence is what matters, failing
+// even where attributes are not supported.
+func setFlagXattr(file *os.File, name string) error {
+	return unix.Fsetxattr(int(file.Fd()), name, []byte{1}, 0)
+}
+
+// Not every file system supports extended attributes; that is not worth
+// failing a transfer over.
+func ignoreUnsupported(err error) error {
+	if err == unix.ENOTSUP {
+		return nil
+	}
+	return err
+}
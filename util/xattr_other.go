@@ -0,0 +1,26 @@
+//go:build !linux
+
+package util
+
+import (
+	"errors"
+	"os"
+)
+
+// Extended attributes are only supported on Linux.
+
+func getXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func getXattr(file *os.File, name string) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func setXattr(file *os.File, name string, value []byte) error {
+	return nil
+}
+
+func setFlagXattr(file *os.File, name string) error {
+	return errors.ErrUnsupported
+}